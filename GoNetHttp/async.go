@@ -0,0 +1,218 @@
+// async.go
+package main
+
+/*
+#include <stdlib.h>
+
+// request_callback 请求完成回调
+// 参数 result 在回调返回后由库释放，调用方需自行复制
+typedef void (*request_callback)(long long id, char* result);
+
+static inline void call_request_callback(request_callback cb, long long id, char* result) {
+	cb(id, result);
+}
+
+// progress_callback 传输进度回调，调用间隔不小于100毫秒，且不会与同一请求的其他回调并发
+// 参数 progress 在回调返回后由库释放
+typedef void (*progress_callback)(long long id, char* progress);
+
+static inline void call_progress_callback(progress_callback cb, long long id, char* progress) {
+	cb(id, progress);
+}
+*/
+import "C"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"main.go/gonethttp"
+)
+
+// 异步请求状态
+const (
+	asyncStatusPending = "pending" // 请求执行中
+	asyncStatusDone    = "done"    // 请求已完成，response为最终结果
+)
+
+// asyncRequest 一次异步提交的请求
+type asyncRequest struct {
+	id       int64
+	done     chan struct{}              // 完成后关闭
+	response map[string]interface{}     // 与PostUrlWithProxy返回值结构一致
+	progress *gonethttp.ProgressTracker // 上传与下载进度
+	callback bool                       // 结果由完成回调交付，不能通过PollRequest/WaitRequest获取
+}
+
+var (
+	asyncSeq      int64 // 请求ID自增序列
+	asyncMu       sync.Mutex
+	asyncRequests = map[int64]*asyncRequest{}
+)
+
+// SubmitRequest 异步提交HTTP请求的C导出函数
+// 参数:
+//
+//	cSpec:     JSON格式请求描述（字段同gonethttp.Request）
+//	cCallback: 可选的完成回调，传NULL表示仅通过PollRequest/WaitRequest获取结果
+//
+// 返回值:
+//
+//	*C.char: {success, result:{id}}，需使用FreeCString释放
+//
+// 注意：设置回调时结果只通过回调交付一次，回调返回后请求ID即失效
+//
+//export SubmitRequest
+func SubmitRequest(cSpec *C.char, cCallback C.request_callback) *C.char {
+	return submitRequest(cSpec, cCallback, nil)
+}
+
+// SubmitRequestWithProgress 异步提交HTTP请求并回调传输进度
+// 参数:
+//
+//	cSpec:     JSON格式请求描述（字段同gonethttp.Request）
+//	cCallback: 可选的完成回调，同SubmitRequest
+//	cProgress: 可选的进度回调，参数为JSON：{id, upload:{bytes, total, rate}, download:{...}}
+//	           total未知时为-1，rate为平均速率（字节/秒）；完成回调之前总会回调一次最终进度
+//
+// 返回值: 同SubmitRequest
+//
+//export SubmitRequestWithProgress
+func SubmitRequestWithProgress(cSpec *C.char, cCallback C.request_callback, cProgress C.progress_callback) *C.char {
+	return submitRequest(cSpec, cCallback, cProgress)
+}
+
+func submitRequest(cSpec *C.char, cCallback C.request_callback, cProgress C.progress_callback) *C.char {
+	spec, err := parseSpec(cSpec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	// 提交时即取得请求体写入器，调用方在请求执行前写完并关闭也不影响发送
+	if err := spec.BodySource.Resolve(); err != nil {
+		return resultToC(nil, err)
+	}
+	ar := &asyncRequest{
+		id:       atomic.AddInt64(&asyncSeq, 1),
+		done:     make(chan struct{}),
+		callback: cCallback != nil,
+	}
+	var notify func(map[string]interface{})
+	if cProgress != nil {
+		notify = func(progress map[string]interface{}) {
+			progress["id"] = ar.id
+			jsonData, _ := json.Marshal(progress)
+			cs := C.CString(string(jsonData))
+			C.call_progress_callback(cProgress, C.longlong(ar.id), cs)
+			C.free(unsafe.Pointer(cs))
+		}
+	}
+	ar.progress = gonethttp.NewProgressTracker(notify)
+	asyncMu.Lock()
+	asyncRequests[ar.id] = ar
+	asyncMu.Unlock()
+	go func() {
+		ctx := gonethttp.WithProgress(context.Background(), ar.progress)
+		ar.response = buildResult(gonethttp.Do(ctx, spec))
+		ar.progress.Finish()
+		if cCallback != nil {
+			// 先注销再标记完成，结果只经回调交付
+			takeAsyncRequest(ar.id)
+		}
+		close(ar.done)
+		if cCallback != nil {
+			jsonData, _ := json.Marshal(ar.response)
+			cs := C.CString(string(jsonData))
+			C.call_request_callback(cCallback, C.longlong(ar.id), cs)
+			C.free(unsafe.Pointer(cs))
+		}
+	}()
+	return resultToC(map[string]interface{}{"id": ar.id}, nil)
+}
+
+// RequestProgress 查询异步请求的传输进度（不阻塞，不影响结果交付）
+// 返回值:
+//
+//	{success:true, result:{id, status:"pending"/"done", upload:{bytes, total, rate}, download:{...}}}
+//	total未知时为-1；结果被取走后请求ID失效，返回错误
+//
+//export RequestProgress
+func RequestProgress(cID C.longlong) *C.char {
+	id := int64(cID)
+	asyncMu.Lock()
+	ar, ok := asyncRequests[id]
+	asyncMu.Unlock()
+	if !ok {
+		return resultToC(nil, fmt.Errorf("未知的请求ID: %d", id))
+	}
+	result := ar.progress.Snapshot()
+	result["id"] = id
+	result["status"] = asyncStatusPending
+	select {
+	case <-ar.done:
+		result["status"] = asyncStatusDone
+	default:
+	}
+	return resultToC(result, nil)
+}
+
+// PollRequest 查询异步请求状态（不阻塞）
+// 返回值:
+//
+//	执行中: {success:true, result:{id, status:"pending"}}
+//	已完成: {success:true, result:{id, status:"done", response:{...}}}，结果交付后请求ID即失效
+//	设置了完成回调的请求返回未知的请求ID错误
+//
+//export PollRequest
+func PollRequest(cID C.longlong) *C.char {
+	return WaitRequest(cID, 0)
+}
+
+// WaitRequest 等待异步请求完成
+// 参数 cTimeoutMs: 最长等待毫秒数，<=0表示不等待；超时后返回pending状态
+// 返回值: 同PollRequest
+//
+//export WaitRequest
+func WaitRequest(cID C.longlong, cTimeoutMs C.longlong) *C.char {
+	id := int64(cID)
+	asyncMu.Lock()
+	ar, ok := asyncRequests[id]
+	asyncMu.Unlock()
+	if !ok || ar.callback {
+		return resultToC(nil, fmt.Errorf("未知的请求ID: %d", id))
+	}
+	if cTimeoutMs > 0 {
+		timer := time.NewTimer(time.Duration(cTimeoutMs) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-ar.done:
+		case <-timer.C:
+		}
+	}
+	select {
+	case <-ar.done:
+	default:
+		return resultToC(map[string]interface{}{"id": id, "status": asyncStatusPending}, nil)
+	}
+	// 结果只交付一次，避免未取走的结果长期占用内存
+	if takeAsyncRequest(id) == nil {
+		return resultToC(nil, fmt.Errorf("未知的请求ID: %d", id))
+	}
+	return resultToC(map[string]interface{}{
+		"id":       id,
+		"status":   asyncStatusDone,
+		"response": ar.response,
+	}, nil)
+}
+
+// takeAsyncRequest 从登记表中取出并移除请求，已被取走时返回nil
+func takeAsyncRequest(id int64) *asyncRequest {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	ar := asyncRequests[id]
+	delete(asyncRequests, id)
+	return ar
+}
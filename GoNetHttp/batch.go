@@ -0,0 +1,40 @@
+// batch.go
+package main
+
+import "C"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"main.go/gonethttp"
+)
+
+// BatchRequest 并发执行一组HTTP请求的C导出函数
+// 参数:
+//
+//	cSpecs:       JSON数组，每一项为一个请求描述（字段同gonethttp.Request）
+//	cConcurrency: 最大并发数，<=0时使用默认值10
+//
+// 返回值:
+//
+//	*C.char: {success, result:[...]}，result按输入顺序排列，
+//	         每一项均为独立的 {success, error, error_code, result} 结构，需使用FreeCString释放
+//
+// 示例：
+//
+//	[{"method": "GET", "url": "https://example.com", "headers": {"User-Agent": "..."}, "proxy": ""}]
+//
+//export BatchRequest
+func BatchRequest(cSpecs *C.char, cConcurrency C.int) *C.char {
+	var specs []*gonethttp.Request
+	if err := json.Unmarshal([]byte(C.GoString(cSpecs)), &specs); err != nil {
+		return resultToC(nil, fmt.Errorf("请求参数解析失败: %v", err))
+	}
+	responses, errs := gonethttp.DoBatch(context.Background(), specs, int(cConcurrency))
+	results := make([]map[string]interface{}, len(specs))
+	for i := range specs {
+		results[i] = buildResult(responses[i], errs[i])
+	}
+	return resultToC(results, nil)
+}
@@ -0,0 +1,58 @@
+// bodywriter.go
+package main
+
+import "C"
+import (
+	"unsafe"
+
+	"main.go/gonethttp"
+)
+
+// OpenBodyWriter 创建分块写入的请求体
+// 参数 cSize: 请求体总字节数，<0表示未知，此时使用chunked传输编码
+// 返回值: {success, result:{writer_id}}，在请求描述中以 {"body_source": {"type": "writer", "writer_id": id}} 引用
+//
+// 使用方式：先用SubmitRequest（或在其他线程中调用同步接口）发起引用该写入器的请求，
+// 再调用WriteBody写入数据，最后调用CloseBodyWriter结束请求体
+//
+//export OpenBodyWriter
+func OpenBodyWriter(cSize C.longlong) *C.char {
+	w := gonethttp.NewBodyWriter(int64(cSize))
+	return resultToC(map[string]interface{}{"writer_id": w.ID()}, nil)
+}
+
+// WriteBody 向请求体写入一块数据
+// 参数 cData/cLength: 数据指针与字节数，可包含任意二进制内容
+// 返回值: {success, result:{writer_id, bytes_written}}，bytes_written为累计写入字节数
+// 数据被请求读取后才返回；请求已结束（失败或已取消）时返回错误
+//
+//export WriteBody
+func WriteBody(cHandle C.longlong, cData *C.char, cLength C.int) *C.char {
+	w, err := gonethttp.LookupBodyWriter(int64(cHandle))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	if _, err := w.Write(C.GoBytes(unsafe.Pointer(cData), cLength)); err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"writer_id": w.ID(), "bytes_written": w.Written()}, nil)
+}
+
+// CloseBodyWriter 结束请求体并释放句柄
+// 参数 cAbort: 非0时中止请求体，发送中的请求以错误结束
+//
+//export CloseBodyWriter
+func CloseBodyWriter(cHandle C.longlong, cAbort C.int) *C.char {
+	w, err := gonethttp.LookupBodyWriter(int64(cHandle))
+	if err == nil {
+		if cAbort != 0 {
+			err = w.Abort()
+		} else {
+			err = w.Close()
+		}
+	}
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"writer_id": w.ID(), "bytes_written": w.Written()}, nil)
+}
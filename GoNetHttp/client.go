@@ -0,0 +1,64 @@
+// client.go
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+
+	"main.go/gonethttp"
+)
+
+// NewClient 创建客户端句柄的C导出函数
+// 参数 cConfig: JSON格式配置，见gonethttp.ClientConfig
+// 返回值: {success, result:{client_id}}，需使用FreeCString释放
+// 请求描述中的client字段引用该ID，同一句柄上的请求共享配置与状态
+//
+//export NewClient
+func NewClient(cConfig *C.char) *C.char {
+	var cfg gonethttp.ClientConfig
+	if err := json.Unmarshal([]byte(C.GoString(cConfig)), &cfg); err != nil {
+		return resultToC(nil, fmt.Errorf("客户端配置解析失败: %v", err))
+	}
+	c, err := gonethttp.NewClient(cfg)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"client_id": c.ID()}, nil)
+}
+
+// CloseClient 释放客户端句柄
+//
+//export CloseClient
+func CloseClient(cID C.longlong) *C.char {
+	c, err := gonethttp.LookupClient(int64(cID))
+	if err == nil {
+		err = c.Close()
+	}
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"client_id": int64(cID)}, nil)
+}
+
+// ImportCookies 向客户端句柄的Cookie罐导入Cookie的C导出函数
+// 参数:
+//
+//	cID:     客户端句柄ID，需在NewClient配置中启用cookie_jar
+//	cData:   Cookie文件内容
+//	cFormat: netscape/json，空字符串时按内容自动识别
+//
+// 返回值: {success, result:{client_id, imported}}，imported为导入的Cookie数（已过期的不计入）
+//
+//export ImportCookies
+func ImportCookies(cID C.longlong, cData *C.char, cFormat *C.char) *C.char {
+	c, err := gonethttp.LookupClient(int64(cID))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	imported, err := c.ImportCookies(C.GoString(cData), C.GoString(cFormat))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"client_id": c.ID(), "imported": imported}, nil)
+}
@@ -0,0 +1,239 @@
+// main.go
+// 命令行工具：与C接口使用同一请求引擎（gonethttp）、同一请求描述与同一JSON返回结构，
+// 用于在Python之外复现问题请求
+//
+// 用法：
+//
+//	gonethttp [选项] [URL]
+//	gonethttp -spec request.json            // 请求描述同DoRequest
+//	gonethttp -settings settings.json -spec request.json
+//	gonethttp -H "User-Agent: demo" -L=false https://example.com   // 不跟随重定向
+//	gonethttp -X POST -H "User-Agent: demo" -d @body.json -x http://127.0.0.1:8080 https://example.com
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"main.go/gonethttp"
+)
+
+// headerFlags 可重复指定的-H参数
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(v string) error {
+	*h = append(*h, v)
+	return nil
+}
+
+// options 命令行参数
+type options struct {
+	spec     string          // 请求描述文件，-表示标准输入
+	method   string          // -X
+	headers  headerFlags     // -H
+	data     string          // -d，@开头时从文件读取
+	proxy    string          // -x
+	follow   bool            // -L
+	insecure bool            // -k，不支持，指定时报错
+	output   string          // -o
+	settings string          // -settings，客户端配置、目标策略与cassette
+	url      string          // 位置参数
+	set      map[string]bool // 显式指定过的选项
+}
+
+func main() {
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	os.Exit(run(opts, os.Stdout, os.Stderr))
+}
+
+// parseArgs 解析命令行参数，允许选项与URL交替出现（与curl一致）
+func parseArgs(args []string) (*options, error) {
+	opts := &options{set: map[string]bool{}}
+	fs := flag.NewFlagSet("gonethttp", flag.ContinueOnError)
+	fs.StringVar(&opts.spec, "spec", "", "JSON请求描述文件（字段同DoRequest），-表示标准输入；其余选项覆盖其中对应字段")
+	fs.StringVar(&opts.method, "X", "", "HTTP方法，未指定时有-d为POST，否则为GET")
+	fs.Var(&opts.headers, "H", "请求头，格式为\"Name: value\"，可重复")
+	fs.StringVar(&opts.data, "d", "", "请求体，@file从文件读取，@-从标准输入读取")
+	fs.StringVar(&opts.proxy, "x", "", "代理地址，格式为scheme://host:port")
+	fs.BoolVar(&opts.follow, "L", true, "跟随重定向，-L=false不跟随；未指定时与DoRequest一致：跟随，使用-spec时以disable_redirect为准")
+	fs.BoolVar(&opts.insecure, "k", false, "不支持：引擎始终不校验证书，无需指定")
+	fs.StringVar(&opts.output, "o", "", "另将响应体原始字节写入文件")
+	fs.StringVar(&opts.settings, "settings", "", "JSON设置文件：client同NewClient，destination_policy同SetDestinationPolicy，cassette同SetCassette")
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) > 1 {
+		return nil, fmt.Errorf("只能指定一个URL: %s", strings.Join(positional, " "))
+	}
+	if len(positional) == 1 {
+		opts.url = positional[0]
+	}
+	fs.Visit(func(f *flag.Flag) { opts.set[f.Name] = true })
+	if opts.set["k"] {
+		return nil, fmt.Errorf("不支持-k: 引擎始终不校验证书，去掉该选项即可")
+	}
+	if opts.spec == "" && opts.url == "" {
+		fs.Usage()
+		return nil, fmt.Errorf("必须指定URL或-spec")
+	}
+	return opts, nil
+}
+
+// run 发送请求并按C接口的返回结构输出，返回进程退出码
+// 响应体写入-o文件失败不影响标准输出中的结果，错误写入stderr并以退出码1结束
+func run(opts *options, stdout, stderr io.Writer) int {
+	res, err := execute(opts)
+	// 与resultToC相同的序列化方式，保证输出与Python侧逐字节一致
+	jsonData, _ := json.Marshal(gonethttp.Envelope(res, err))
+	fmt.Fprintln(stdout, string(jsonData))
+	if err != nil {
+		return 1
+	}
+	if opts.output != "" {
+		if err := os.WriteFile(opts.output, res.Body, 0o644); err != nil {
+			fmt.Fprintf(stderr, "响应体写入文件失败: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// execute 应用设置文件、构造请求描述并发送请求
+func execute(opts *options) (*gonethttp.Response, error) {
+	client, err := applySettings(opts.settings)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := buildSpec(opts)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		defer client.Close()
+		return client.Do(context.Background(), spec)
+	}
+	return gonethttp.Do(context.Background(), spec)
+}
+
+// settings -settings文件内容，各字段与C接口对应函数的JSON参数相同，未给出的字段不做设置
+type settings struct {
+	Client      *gonethttp.ClientConfig      `json:"client"`             // 同NewClient，请求经该客户端发送
+	Destination *gonethttp.DestinationConfig `json:"destination_policy"` // 同SetDestinationPolicy，全局目标地址策略
+	Cassette    *gonethttp.CassetteConfig    `json:"cassette"`           // 同SetCassette，全局录制/回放
+}
+
+// applySettings 读取设置文件并应用全局设置，配置了client时返回新建的客户端
+func applySettings(path string) (*gonethttp.Client, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := readSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("请求参数解析失败: 设置文件读取失败: %v", err)
+	}
+	var s settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("请求参数解析失败: 设置文件解析失败: %v", err)
+	}
+	if s.Destination != nil {
+		if err := gonethttp.SetDestinationPolicy(s.Destination); err != nil {
+			return nil, err
+		}
+	}
+	if s.Cassette != nil {
+		if _, err := gonethttp.SetCassette(*s.Cassette); err != nil {
+			return nil, err
+		}
+	}
+	if s.Client == nil {
+		return nil, nil
+	}
+	return gonethttp.NewClient(*s.Client)
+}
+
+// buildSpec 由请求描述文件与命令行选项构造请求
+// 未显式给出的字段取DoRequest的默认值（如跟随重定向），保证与库调用的行为一致；
+// 指定-spec时显式给出的选项覆盖文件中的字段
+func buildSpec(opts *options) (*gonethttp.Request, error) {
+	spec := &gonethttp.Request{}
+	if opts.spec != "" {
+		data, err := readSource(opts.spec)
+		if err != nil {
+			return nil, fmt.Errorf("请求参数解析失败: %v", err)
+		}
+		if err := json.Unmarshal(data, spec); err != nil {
+			return nil, fmt.Errorf("请求参数解析失败: %v", err)
+		}
+	}
+
+	if opts.url != "" {
+		spec.URL = opts.url
+	}
+	if opts.set["d"] {
+		body := opts.data
+		if strings.HasPrefix(body, "@") {
+			data, err := readSource(body[1:])
+			if err != nil {
+				return nil, fmt.Errorf("请求体来源无效: %v", err)
+			}
+			body = string(data)
+		}
+		spec.Body = body
+	}
+	switch {
+	case opts.set["X"]:
+		spec.Method = opts.method
+	case spec.Method == "" && opts.set["d"]:
+		spec.Method = "POST"
+	case spec.Method == "":
+		spec.Method = "GET"
+	}
+	for _, h := range opts.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("headers参数解析失败: 请求头格式应为\"Name: value\": %s", h)
+		}
+		if spec.Headers == nil {
+			spec.Headers = map[string]string{}
+		}
+		spec.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if opts.set["x"] {
+		spec.Proxy = opts.proxy
+	}
+	if opts.set["L"] {
+		spec.DisableRedirect = !opts.follow
+	}
+	return spec, nil
+}
+
+// readSource 读取文件内容，-表示标准输入
+func readSource(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
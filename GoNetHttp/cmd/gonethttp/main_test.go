@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"main.go/gonethttp"
+)
+
+// writeFile 在临时目录中写入文件并返回路径
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuildSpec(t *testing.T) {
+	body := writeFile(t, "body.json", `{"a": 1}`)
+	spec := writeFile(t, "spec.json", `{"method": "POST", "url": "https://spec.example", "headers": {"User-Agent": "spec", "X-A": "1"},
+		"proxy": "http://spec-proxy:8080", "disable_redirect": true, "body": "from spec"}`)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want gonethttp.Request
+	}{
+		{
+			"defaults follow redirects",
+			[]string{"https://example.com"},
+			gonethttp.Request{Method: "GET", URL: "https://example.com"},
+		},
+		{
+			"headers",
+			[]string{"-H", "User-Agent: demo", "-H", "X-Test:  a:b ", "https://example.com"},
+			gonethttp.Request{Method: "GET", URL: "https://example.com", Headers: map[string]string{"User-Agent": "demo", "X-Test": "a:b"}},
+		},
+		{
+			"body from file",
+			[]string{"-d", "@" + body, "https://example.com"},
+			gonethttp.Request{Method: "POST", URL: "https://example.com", Body: `{"a": 1}`},
+		},
+		{
+			"explicit method with body",
+			[]string{"-X", "GET", "-d", "q=1", "https://example.com"},
+			gonethttp.Request{Method: "GET", URL: "https://example.com", Body: "q=1"},
+		},
+		{
+			"proxy and no redirect",
+			[]string{"-x", "socks5://127.0.0.1:1080", "-L=false", "https://example.com"},
+			gonethttp.Request{Method: "GET", URL: "https://example.com", Proxy: "socks5://127.0.0.1:1080", DisableRedirect: true},
+		},
+		{
+			"options after url",
+			[]string{"https://example.com", "-H", "User-Agent: demo"},
+			gonethttp.Request{Method: "GET", URL: "https://example.com", Headers: map[string]string{"User-Agent": "demo"}},
+		},
+		{
+			"spec only",
+			[]string{"-spec", spec},
+			gonethttp.Request{Method: "POST", URL: "https://spec.example", Headers: map[string]string{"User-Agent": "spec", "X-A": "1"},
+				Proxy: "http://spec-proxy:8080", DisableRedirect: true, Body: "from spec"},
+		},
+		{
+			"options override spec",
+			[]string{"-spec", spec, "-H", "User-Agent: cli", "-x", "", "-L", "-d", "from cli", "https://cli.example"},
+			gonethttp.Request{Method: "POST", URL: "https://cli.example", Headers: map[string]string{"User-Agent": "cli", "X-A": "1"},
+				Body: "from cli"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := parseArgs(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := buildSpec(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Method != tc.want.Method || got.URL != tc.want.URL || got.Proxy != tc.want.Proxy ||
+				got.DisableRedirect != tc.want.DisableRedirect || got.Body != tc.want.Body || !maps.Equal(got.Headers, tc.want.Headers) {
+				t.Fatalf("got %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"-k", "https://example.com"},
+		{"https://a.example", "https://b.example"},
+	} {
+		if _, err := parseArgs(args); err == nil {
+			t.Errorf("%q: no error", args)
+		}
+	}
+}
+
+func TestBuildSpecErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"-H", "no colon", "https://example.com"}, gonethttp.ErrHeaderParse},
+		{[]string{"-d", "@" + filepath.Join(t.TempDir(), "missing"), "https://example.com"}, gonethttp.ErrBodySource},
+		{[]string{"-spec", writeFile(t, "bad.json", "{")}, gonethttp.ErrSpecParse},
+	} {
+		opts, err := parseArgs(tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := buildSpec(opts); gonethttp.ErrorCode(err) != tc.code {
+			t.Errorf("%q: error = %v, want code %d", tc.args, err, tc.code)
+		}
+	}
+}
+
+// runArgs 解析参数并运行，返回退出码、标准输出中的JSON与标准错误
+func runArgs(t *testing.T, args ...string) (int, map[string]interface{}, string) {
+	t.Helper()
+	opts, err := parseArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(opts, &stdout, &stderr)
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &envelope); err != nil {
+		t.Fatalf("stdout %q is not JSON: %v", stdout.String(), err)
+	}
+	return code, envelope, stderr.String()
+}
+
+func TestRunOutputFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "payload")
+	}))
+	defer srv.Close()
+
+	output := filepath.Join(t.TempDir(), "body.bin")
+	code, envelope, _ := runArgs(t, "-H", "User-Agent: test", "-o", output, srv.URL)
+	if data, _ := os.ReadFile(output); code != 0 || envelope["success"] != true || string(data) != "payload" {
+		t.Fatalf("exit %d, envelope %v, file %q", code, envelope, data)
+	}
+
+	// 写入失败时仍输出请求结果
+	output = filepath.Join(t.TempDir(), "missing", "body.bin")
+	code, envelope, stderr := runArgs(t, "-H", "User-Agent: test", "-o", output, srv.URL)
+	result, _ := envelope["result"].(map[string]interface{})
+	if code != 1 || envelope["success"] != true || result == nil || result["body"] != "payload" {
+		t.Fatalf("exit %d, envelope %v, want exit 1 with the response", code, envelope)
+	}
+	if !strings.Contains(stderr, "响应体写入文件失败") {
+		t.Fatalf("stderr = %q", stderr)
+	}
+}
+
+func TestRunSettingsWithSpec(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// 请求描述中的主机名只能由设置文件中客户端的静态解析找到
+	spec := writeFile(t, "spec.json", `{"method": "GET", "url": "http://api.test:`+port+`", "headers": {"User-Agent": "test"}}`)
+	settings := writeFile(t, "settings.json", `{"client": {"dns": {"hosts": {"api.test": "127.0.0.1"}}}}`)
+	code, envelope, _ := runArgs(t, "-settings", settings, "-spec", spec)
+	result, _ := envelope["result"].(map[string]interface{})
+	if code != 0 || result == nil || result["body"] != "api.test:"+port {
+		t.Fatalf("exit %d, envelope %v", code, envelope)
+	}
+
+	code, envelope, _ = runArgs(t, "-settings", writeFile(t, "bad.json", "{"), "-spec", spec)
+	if code != 1 || envelope["error_code"] != float64(gonethttp.ErrSpecParse) {
+		t.Fatalf("exit %d, envelope %v, want a settings parse error", code, envelope)
+	}
+}
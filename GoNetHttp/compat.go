@@ -0,0 +1,28 @@
+// compat.go
+package main
+
+import "C"
+
+// 旧版构建的PostUrlWithProxy签名
+// C符号不能重载，旧签名以版本号后缀导出，调用方只需替换函数名即可切换到当前库：
+//
+//	v1: PostUrlWithProxy(method, url, headers, proxy)，不跟随重定向、不发送请求体
+//	v2: PostUrlWithProxy(method, url, headers, proxy, disableRedirect)，增加重定向开关
+//	v3: PostUrlWithProxy(method, url, headers, proxy, disableRedirect, body)，即当前的PostUrlWithProxy（libproxy5/libproxy6）
+//
+// v1与v2构建发送前移除headers中名为Authorization的请求头（键名区分大小写），兼容签名保持这一行为；v3起不再移除。
+// 返回值与当前PostUrlWithProxy一致，在旧版结构的基础上增加了字段
+
+// PostUrlWithProxyV1 v1签名的PostUrlWithProxy
+//
+//export PostUrlWithProxyV1
+func PostUrlWithProxyV1(cMethod, cGetUrl, cHeaders, cProxyUrl *C.char) *C.char {
+	return postUrl(cMethod, cGetUrl, cHeaders, cProxyUrl, true, "", true)
+}
+
+// PostUrlWithProxyV2 v2签名的PostUrlWithProxy
+//
+//export PostUrlWithProxyV2
+func PostUrlWithProxyV2(cMethod, cGetUrl, cHeaders, cProxyUrl, cDisableRedirect *C.char) *C.char {
+	return postUrl(cMethod, cGetUrl, cHeaders, cProxyUrl, C.GoString(cDisableRedirect) == "true", "", true)
+}
@@ -1,3 +1,17 @@
 module main.go
 
-go 1.21.5
+go 1.24.0
+
+require (
+	github.com/andybalholm/brotli v1.0.6
+	github.com/gorilla/websocket v1.5.3
+	github.com/klauspost/compress v1.17.4
+	github.com/refraction-networking/utls v1.8.2
+	golang.org/x/crypto v0.44.0
+	golang.org/x/net v0.47.0
+)
+
+require (
+	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+)
@@ -0,0 +1,312 @@
+// auth.go
+package gonethttp
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 认证方式
+const (
+	authBasic  = "basic"
+	authBearer = "bearer"
+	authDigest = "digest"
+)
+
+// AuthConfig 请求描述中的auth字段
+// 凭据只发送给请求URL的源（协议+主机+端口），重定向到其他源时不携带
+//
+// 示例：
+//
+//	{"type": "basic", "username": "user", "password": "pass"}
+//	{"type": "bearer", "token": "xxx"}
+//	{"type": "digest", "username": "user", "password": "pass"}
+type AuthConfig struct {
+	Type     string `json:"type"`               // basic/bearer/digest
+	Username string `json:"username,omitempty"` // basic与digest的用户名
+	Password string `json:"password,omitempty"` // basic与digest的密码
+	Token    string `json:"token,omitempty"`    // bearer令牌
+}
+
+// validate 校验认证配置
+func (a *AuthConfig) validate() error {
+	if a == nil {
+		return nil
+	}
+	a.Type = strings.ToLower(a.Type)
+	switch a.Type {
+	case authBasic, authDigest:
+		if a.Username == "" {
+			return fmt.Errorf("认证配置无效: %s认证需要username", a.Type)
+		}
+	case authBearer:
+		if a.Token == "" {
+			return fmt.Errorf("认证配置无效: bearer认证需要token")
+		}
+	default:
+		return fmt.Errorf("认证配置无效: 未知的认证方式 %s", a.Type)
+	}
+	return nil
+}
+
+// digestChallenge 服务器下发的Digest质询及其nonce计数
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // 为空表示服务器未要求qop（RFC 2069兼容）
+	nc        uint32
+}
+
+// digestCache 按源保存最近一次质询，同一客户端句柄上的请求复用nonce并递增计数
+type digestCache struct {
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{challenges: map[string]*digestChallenge{}}
+}
+
+// authorize 使用缓存的质询生成Authorization，没有质询时返回空字符串
+func (d *digestCache) authorize(origin string, auth *AuthConfig, method, uri string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch, ok := d.challenges[origin]
+	if !ok {
+		return "", nil
+	}
+	ch.nc++
+	return ch.response(auth, method, uri)
+}
+
+func (d *digestCache) store(origin string, ch *digestChallenge) {
+	d.mu.Lock()
+	d.challenges[origin] = ch
+	d.mu.Unlock()
+}
+
+// authTransport 为发往原始源的请求添加认证信息，并应答401质询
+type authTransport struct {
+	base   http.RoundTripper
+	auth   *AuthConfig
+	origin string
+	digest *digestCache
+}
+
+// newAuthTransport 在传输层外包装认证，未配置认证时原样返回
+// 参数 digest: 客户端句柄共享的质询缓存，为nil时只在本次请求内有效
+func newAuthTransport(base http.RoundTripper, auth *AuthConfig, target *url.URL, digest *digestCache) http.RoundTripper {
+	if auth == nil {
+		return base
+	}
+	if digest == nil {
+		digest = newDigestCache()
+	}
+	return &authTransport{base: base, auth: auth, origin: originOf(target), digest: digest}
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if originOf(req.URL) != t.origin {
+		return t.base.RoundTrip(req)
+	}
+	switch t.auth.Type {
+	case authBasic:
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.auth.Username, t.auth.Password)
+		return t.base.RoundTrip(req)
+	case authBearer:
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.auth.Token)
+		return t.base.RoundTrip(req)
+	}
+	// digest：有缓存的质询时直接携带，否则先发送无凭据请求等待401
+	uri := req.URL.RequestURI()
+	header, err := t.digest.authorize(t.origin, t.auth, req.Method, uri)
+	if err != nil {
+		return nil, err
+	}
+	first := req
+	if header != "" {
+		first = req.Clone(req.Context())
+		first.Header.Set("Authorization", header)
+	}
+	res, err := t.base.RoundTrip(first)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	ch := parseDigestChallenge(res.Header.Values("WWW-Authenticate"))
+	if ch == nil {
+		return res, nil
+	}
+	// 重发需要重新获取请求体
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return res, nil
+		}
+		body, errBody := req.GetBody()
+		if errBody != nil {
+			return res, nil
+		}
+		retry.Body = body
+	}
+	ch.nc = 1
+	header, err = ch.response(t.auth, req.Method, uri)
+	if err != nil {
+		return res, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	res.Body.Close()
+	t.digest.store(t.origin, ch)
+	retry.Header.Set("Authorization", header)
+	return t.base.RoundTrip(retry)
+}
+
+// response 按RFC 7616计算Digest认证头
+func (ch *digestChallenge) response(auth *AuthConfig, method, uri string) (string, error) {
+	var newHash func() hash.Hash
+	algorithm := strings.ToUpper(ch.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("认证配置无效: 不支持的Digest算法 %s", ch.algorithm)
+	}
+	h := func(parts ...string) string {
+		sum := newHash()
+		io.WriteString(sum, strings.Join(parts, ":"))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	cnonce := randomHex(16)
+	nc := fmt.Sprintf("%08x", ch.nc)
+	ha1 := h(auth.Username, ch.realm, auth.Password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1, ch.nonce, cnonce)
+	}
+	ha2 := h(method, uri)
+	var response string
+	if ch.qop == "" {
+		response = h(ha1, ch.nonce, ha2)
+	} else {
+		response = h(ha1, ch.nonce, nc, cnonce, ch.qop, ha2)
+	}
+	params := []string{
+		fmt.Sprintf(`username="%s"`, auth.Username),
+		fmt.Sprintf(`realm="%s"`, ch.realm),
+		fmt.Sprintf(`nonce="%s"`, ch.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if ch.algorithm != "" {
+		params = append(params, "algorithm="+ch.algorithm)
+	}
+	if ch.opaque != "" {
+		params = append(params, fmt.Sprintf(`opaque="%s"`, ch.opaque))
+	}
+	if ch.qop != "" {
+		params = append(params, "qop="+ch.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// parseDigestChallenge 从WWW-Authenticate中找出Digest质询
+// 服务器同时提供多个算法时优先SHA-256；只支持qop=auth
+func parseDigestChallenge(values []string) *digestChallenge {
+	var best *digestChallenge
+	for _, value := range values {
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(value[7:])
+		ch := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, option := range strings.Split(qop, ",") {
+				if strings.TrimSpace(option) == "auth" {
+					ch.qop = "auth"
+				}
+			}
+			if ch.qop == "" {
+				continue
+			}
+		}
+		if ch.nonce == "" {
+			continue
+		}
+		if best == nil || strings.HasPrefix(strings.ToUpper(ch.algorithm), "SHA-256") {
+			best = ch
+		}
+	}
+	return best
+}
+
+// parseAuthParams 解析 key=value, key="quoted value" 形式的认证参数
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+}
+
+// originOf 返回URL的源：协议://主机:端口（端口缺省时按协议补全）
+func originOf(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" || scheme == "wss" {
+			port = "443"
+		}
+	}
+	return scheme + "://" + strings.ToLower(u.Hostname()) + ":" + port
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,97 @@
+package gonethttp
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// digestServer 要求qop=auth的MD5 Digest认证，记录收到的nc
+type digestServer struct {
+	mu         sync.Mutex
+	challenges int
+	ncs        []string
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	const realm, nonce = "test", "abc123"
+	params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+	h := func(parts ...string) string {
+		sum := md5.Sum([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum[:])
+	}
+	want := h(h("user", realm, "pass"), nonce, params["nc"], params["cnonce"], "auth", h(r.Method, params["uri"]))
+	if params["response"] == "" || params["response"] != want || params["uri"] != r.URL.RequestURI() {
+		s.challenges++
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth"`, realm, nonce))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.ncs = append(s.ncs, params["nc"])
+	body, _ := io.ReadAll(r.Body)
+	fmt.Fprintf(w, "ok %s", body)
+}
+
+func TestAuthBasicAndBearer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		auth *AuthConfig
+		want string
+	}{
+		{&AuthConfig{Type: "basic", Username: "user", Password: "pass"}, "Basic dXNlcjpwYXNz"},
+		{&AuthConfig{Type: "Bearer", Token: "tok"}, "Bearer tok"},
+	} {
+		res, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, Auth: tc.auth})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text != tc.want {
+			t.Errorf("%s: Authorization = %q, want %q", tc.auth.Type, res.Text, tc.want)
+		}
+	}
+}
+
+func TestAuthDigest(t *testing.T) {
+	handler := &digestServer{}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	auth := &AuthConfig{Type: "digest", Username: "user", Password: "pass"}
+	for i := 0; i < 2; i++ {
+		res, err := c.Do(context.Background(), &Request{
+			Method:  "POST",
+			URL:     fmt.Sprintf("%s/p?i=%d", srv.URL, i),
+			Headers: map[string]string{"User-Agent": "test"},
+			Body:    "payload",
+			Auth:    auth,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK || res.Text != "ok payload" {
+			t.Fatalf("request %d: got %d %q, want 200 with the body replayed", i, res.StatusCode, res.Text)
+		}
+	}
+	// 第二个请求复用句柄内的质询，不再收到401
+	if handler.challenges != 1 || strings.Join(handler.ncs, ",") != "00000001,00000002" {
+		t.Fatalf("challenges = %d, nc = %v; want one challenge and nc 1,2", handler.challenges, handler.ncs)
+	}
+}
@@ -0,0 +1,46 @@
+// batch.go
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultBatchConcurrency 未指定并发数时的默认值
+const defaultBatchConcurrency = 10
+
+// DoBatch 使用固定数量的goroutine并发执行一组请求
+// 参数 concurrency: 最大并发数，<=0时使用默认值10
+// 返回值与输入一一对应，单个请求失败不影响其他请求
+func DoBatch(ctx context.Context, reqs []*Request, concurrency int) ([]*Response, []error) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if concurrency > len(reqs) {
+		concurrency = len(reqs)
+	}
+	results := make([]*Response, len(reqs))
+	errs := make([]error, len(reqs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if reqs[i] == nil {
+					errs[i] = fmt.Errorf("请求参数解析失败: 第%d项为空", i)
+					continue
+				}
+				results[i], errs[i] = Do(ctx, reqs[i])
+			}
+		}()
+	}
+	for i := range reqs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
+}
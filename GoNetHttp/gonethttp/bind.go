@@ -0,0 +1,129 @@
+// bind.go
+package gonethttp
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// 本地地址轮换方式
+const (
+	rotationRoundRobin = "round_robin" // 按顺序轮换（默认）
+	rotationRandom     = "random"      // 随机选择
+)
+
+// BindConfig 本地出口地址绑定配置
+// 轮换以新建连接为单位，复用中的长连接保持原来的出口地址
+//
+// 示例：
+//
+//	{"addresses": ["10.0.0.2", "10.0.0.3"], "rotation": "round_robin"}
+//	{"interface": "eth1"}
+type BindConfig struct {
+	Addresses []string `json:"addresses"` // 本地IP列表
+	Interface string   `json:"interface"` // 网卡名称，使用该网卡上的全部地址（与addresses合并）
+	Rotation  string   `json:"rotation"`  // 轮换方式：round_robin/random
+}
+
+// binder 为新连接选择本地出口地址
+type binder struct {
+	addrs  []net.IP
+	random bool
+	next   uint64
+}
+
+// newBinder 根据配置创建绑定器，未配置时返回nil
+func newBinder(cfg *BindConfig) (*binder, error) {
+	if cfg == nil || (len(cfg.Addresses) == 0 && cfg.Interface == "") {
+		return nil, nil
+	}
+	b := &binder{}
+	switch strings.ToLower(cfg.Rotation) {
+	case "", rotationRoundRobin:
+	case rotationRandom:
+		b.random = true
+	default:
+		return nil, fmt.Errorf("本地地址配置错误: 未知的轮换方式 %s", cfg.Rotation)
+	}
+	for _, addr := range cfg.Addresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("本地地址配置错误: %q 不是IP地址", addr)
+		}
+		b.addrs = append(b.addrs, ip)
+	}
+	if cfg.Interface != "" {
+		ips, err := interfaceAddrs(cfg.Interface)
+		if err != nil {
+			return nil, err
+		}
+		b.addrs = append(b.addrs, ips...)
+	}
+	return b, nil
+}
+
+// parseLocalAddress 解析请求级的local_address字段：IP地址或网卡名称
+func parseLocalAddress(value string) (*binder, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if net.ParseIP(value) != nil {
+		return newBinder(&BindConfig{Addresses: []string{value}})
+	}
+	return newBinder(&BindConfig{Interface: value})
+}
+
+// interfaceAddrs 读取网卡上可用于出站连接的地址（排除IPv6链路本地地址）
+func interfaceAddrs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("本地地址配置错误: 网卡 %s 不存在: %v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("本地地址配置错误: 读取网卡 %s 地址失败: %v", name, err)
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("本地地址配置错误: 网卡 %s 没有可用地址", name)
+	}
+	return ips, nil
+}
+
+// dialerFor 为目标地址选择同一IP版本的本地地址并返回拨号器
+// 绑定器为nil时返回默认拨号器
+func (b *binder) dialerFor(target net.IP) (*net.Dialer, error) {
+	if b == nil {
+		return baseDialer, nil
+	}
+	var candidates []net.IP
+	for _, ip := range b.addrs {
+		if (ip.To4() != nil) == (target.To4() != nil) {
+			candidates = append(candidates, ip)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("本地地址绑定失败: 没有与目标 %s 同一IP版本的本地地址", target)
+	}
+	var local net.IP
+	if b.random {
+		local = candidates[rand.Intn(len(candidates))]
+	} else {
+		local = candidates[(atomic.AddUint64(&b.next, 1)-1)%uint64(len(candidates))]
+	}
+	return &net.Dialer{
+		Timeout:   baseDialer.Timeout,
+		KeepAlive: baseDialer.KeepAlive,
+		LocalAddr: &net.TCPAddr{IP: local},
+	}, nil
+}
@@ -0,0 +1,244 @@
+// bodysource.go
+package gonethttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// 请求体来源类型
+const (
+	bodySourceFile   = "file"   // 从文件读取
+	bodySourceWriter = "writer" // 由调用方通过BodyWriter分块写入
+)
+
+// BodySource 请求描述中的body_source字段，替代body以流式发送请求体
+// 文件来源的长度已知，发送Content-Length；写入器未声明大小时使用chunked传输编码
+//
+// 示例：
+//
+//	{"type": "file", "path": "/data/upload.bin", "offset": 1048576, "length": 4194304}
+//	{"type": "writer", "writer_id": 1}
+type BodySource struct {
+	Type     string `json:"type"`                // file/writer
+	Path     string `json:"path,omitempty"`      // 文件路径
+	Offset   int64  `json:"offset,omitempty"`    // 起始偏移
+	Length   int64  `json:"length,omitempty"`    // 发送的字节数，<=0表示到文件末尾
+	WriterID int64  `json:"writer_id,omitempty"` // 写入器ID（BodyWriter.ID）
+
+	writer *BodyWriter // Resolve取得的写入器
+}
+
+// Resolve 按writer_id取得写入器并保存在来源中，之后调用方关闭（注销）写入器不影响请求读取已写入的数据
+// 异步提交的请求应在提交时调用，避免请求开始执行前写入器已被关闭；同步发送时由校验完成
+func (b *BodySource) Resolve() error {
+	if b == nil || b.Type != bodySourceWriter || b.writer != nil {
+		return nil
+	}
+	w, err := LookupBodyWriter(b.WriterID)
+	if err != nil {
+		return err
+	}
+	b.writer = w
+	return nil
+}
+
+// validate 校验请求体来源
+func (b *BodySource) validate(spec *Request) error {
+	if b == nil {
+		return nil
+	}
+	if spec.Body != "" {
+		return fmt.Errorf("请求体来源无效: body与body_source不能同时指定")
+	}
+	switch b.Type {
+	case bodySourceFile:
+		if b.Path == "" {
+			return fmt.Errorf("请求体来源无效: 缺少path")
+		}
+		if b.Offset < 0 {
+			return fmt.Errorf("请求体来源无效: offset不能为负数")
+		}
+	case bodySourceWriter:
+		if err := b.Resolve(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("请求体来源无效: 未知的类型 %s", b.Type)
+	}
+	return nil
+}
+
+// attach 打开请求体并设置到请求上
+// 文件来源可通过GetBody重新打开，重定向与认证重发时能再次发送；写入器只能发送一次
+func (b *BodySource) attach(req *http.Request) error {
+	if b.Type == bodySourceWriter {
+		if err := b.Resolve(); err != nil {
+			return err
+		}
+		w := b.writer
+		body, err := w.claim()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+		req.ContentLength = w.size
+		req.GetBody = nil
+		if w.size == 0 {
+			body.Close()
+			req.Body = http.NoBody
+		}
+		return nil
+	}
+	open := func() (io.ReadCloser, int64, error) {
+		f, err := os.Open(b.Path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("请求体来源无效: %v", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, fmt.Errorf("请求体来源无效: %v", err)
+		}
+		if b.Offset > info.Size() {
+			f.Close()
+			return nil, 0, fmt.Errorf("请求体来源无效: offset %d超出文件大小%d", b.Offset, info.Size())
+		}
+		length := info.Size() - b.Offset
+		if b.Length > 0 {
+			length = min(b.Length, length)
+		}
+		return &sectionFile{Reader: io.NewSectionReader(f, b.Offset, length), file: f}, length, nil
+	}
+	body, length, err := open()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	req.ContentLength = length
+	req.GetBody = func() (io.ReadCloser, error) {
+		body, _, err := open()
+		return body, err
+	}
+	return nil
+}
+
+// abort 请求在读取请求体之前失败时关闭写入器的管道，避免Write一直阻塞
+func (b *BodySource) abort(err error) {
+	if b == nil || b.Type != bodySourceWriter {
+		return
+	}
+	if b.Resolve() == nil {
+		b.writer.pr.CloseWithError(err)
+	}
+}
+
+// sectionFile 读取文件的一段，关闭时关闭文件
+type sectionFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (s *sectionFile) Close() error {
+	return s.file.Close()
+}
+
+// BodyWriter 调用方分块写入的请求体
+// 写入通过管道直接交给发送中的请求，Write在数据被发送前阻塞，不在内存中累积
+//
+// 使用方式：先在其他goroutine中发起引用该写入器的请求，再调用Write写入数据，最后调用Close结束请求体
+type BodyWriter struct {
+	id      int64
+	size    int64 // 声明的总大小，-1表示未知（使用chunked传输编码）
+	pr      *io.PipeReader
+	pw      *io.PipeWriter
+	claimed atomic.Bool // 已被请求使用
+	written atomic.Int64
+}
+
+var (
+	bodyWriterSeq int64 // 写入器ID自增序列
+	bodyWritersMu sync.Mutex
+	bodyWriters   = map[int64]*BodyWriter{}
+)
+
+// NewBodyWriter 创建分块写入的请求体并登记
+// 参数 size: 请求体总字节数，<0表示未知，此时使用chunked传输编码
+// 在请求描述中以 {"body_source": {"type": "writer", "writer_id": id}} 引用
+func NewBodyWriter(size int64) *BodyWriter {
+	pr, pw := io.Pipe()
+	w := &BodyWriter{
+		id:   atomic.AddInt64(&bodyWriterSeq, 1),
+		size: max(size, -1),
+		pr:   pr,
+		pw:   pw,
+	}
+	bodyWritersMu.Lock()
+	bodyWriters[w.id] = w
+	bodyWritersMu.Unlock()
+	return w
+}
+
+// LookupBodyWriter 按ID查找未关闭的写入器
+func LookupBodyWriter(id int64) (*BodyWriter, error) {
+	bodyWritersMu.Lock()
+	defer bodyWritersMu.Unlock()
+	w, ok := bodyWriters[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的句柄: 请求体写入器 %d", id)
+	}
+	return w, nil
+}
+
+// ID 写入器ID，即body_source中writer_id的取值
+func (w *BodyWriter) ID() int64 {
+	return w.id
+}
+
+// Written 累计写入的字节数
+func (w *BodyWriter) Written() int64 {
+	return w.written.Load()
+}
+
+// Write 写入一块数据，数据被请求读取后才返回；请求已结束（失败或已取消）时返回错误
+func (w *BodyWriter) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	w.written.Add(int64(n))
+	if err != nil {
+		return n, fmt.Errorf("请求体写入失败: %v", err)
+	}
+	return n, nil
+}
+
+// Close 结束请求体并注销写入器
+func (w *BodyWriter) Close() error {
+	return w.close(nil)
+}
+
+// Abort 中止请求体并注销写入器，发送中的请求以错误结束
+func (w *BodyWriter) Abort() error {
+	return w.close(fmt.Errorf("请求体写入已中止"))
+}
+
+func (w *BodyWriter) close(reason error) error {
+	bodyWritersMu.Lock()
+	_, ok := bodyWriters[w.id]
+	delete(bodyWriters, w.id)
+	bodyWritersMu.Unlock()
+	if !ok {
+		return fmt.Errorf("未知的句柄: 请求体写入器 %d", w.id)
+	}
+	return w.pw.CloseWithError(reason)
+}
+
+// claim 由请求取得管道的读取端，每个写入器只能被一个请求使用
+func (w *BodyWriter) claim() (io.ReadCloser, error) {
+	if !w.claimed.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("请求体来源无效: 写入器 %d 已被其他请求使用", w.id)
+	}
+	return w.pr, nil
+}
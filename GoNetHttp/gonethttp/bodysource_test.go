@@ -0,0 +1,144 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bodyEchoHandler 返回请求体长度声明、传输编码与请求体
+func bodyEchoHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "%d %v %s", r.ContentLength, r.TransferEncoding, body)
+}
+
+func TestBodySourceFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(bodyEchoHandler))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Do(context.Background(), &Request{
+		Method:     "POST",
+		URL:        srv.URL,
+		Headers:    map[string]string{"User-Agent": "test"},
+		BodySource: &BodySource{Type: "file", Path: path, Offset: 2, Length: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "5 [] 23456" {
+		t.Fatalf("got %q, want the 5-byte section with Content-Length", res.Text)
+	}
+}
+
+func TestBodySourceWriter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(bodyEchoHandler))
+	defer srv.Close()
+
+	w := NewBodyWriter(-1)
+	done := make(chan *Response, 1)
+	go func() {
+		res, err := Do(context.Background(), &Request{
+			Method:     "POST",
+			URL:        srv.URL,
+			Headers:    map[string]string{"User-Agent": "test"},
+			BodySource: &BodySource{Type: "writer", WriterID: w.ID()},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- res
+	}()
+	for _, chunk := range []string{"chunk1,", "chunk2"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	res := <-done
+	if res == nil || res.Text != "-1 [chunked] chunk1,chunk2" {
+		t.Fatalf("got %+v, want a chunked body", res)
+	}
+	if w.Written() != int64(len("chunk1,chunk2")) {
+		t.Fatalf("written = %d", w.Written())
+	}
+}
+
+func TestBodySourceWriterResolvedBeforeClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(bodyEchoHandler))
+	defer srv.Close()
+
+	// 异步提交时先取得写入器，请求开始执行前调用方已写完并关闭
+	w := NewBodyWriter(-1)
+	source := &BodySource{Type: "writer", WriterID: w.ID()}
+	if err := source.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	res, err := Do(context.Background(), &Request{
+		Method:     "POST",
+		URL:        srv.URL,
+		Headers:    map[string]string{"User-Agent": "test"},
+		BodySource: source,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "-1 [chunked] " {
+		t.Fatalf("got %q, want an empty chunked body", res.Text)
+	}
+}
+
+func TestBodySourceWriterAbort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(bodyEchoHandler))
+	defer srv.Close()
+
+	w := NewBodyWriter(100)
+	done := make(chan error, 1)
+	go func() {
+		_, err := Do(context.Background(), &Request{
+			Method:     "POST",
+			URL:        srv.URL,
+			Headers:    map[string]string{"User-Agent": "test"},
+			BodySource: &BodySource{Type: "writer", WriterID: w.ID()},
+		})
+		done <- err
+	}()
+	w.Write([]byte("partial"))
+	w.Abort()
+	if err := <-done; ErrorCode(err) != ErrBodyWrite {
+		t.Fatalf("error = %v, want code %d", err, ErrBodyWrite)
+	}
+}
+
+func TestBodySourceErrors(t *testing.T) {
+	for _, tc := range []struct {
+		req  Request
+		code int
+	}{
+		{Request{Body: "x", BodySource: &BodySource{Type: "file", Path: "a"}}, ErrBodySource},
+		{Request{BodySource: &BodySource{Type: "file"}}, ErrBodySource},
+		{Request{BodySource: &BodySource{Type: "socket"}}, ErrBodySource},
+		{Request{BodySource: &BodySource{Type: "file", Path: filepath.Join(t.TempDir(), "missing")}}, ErrBodySource},
+		{Request{BodySource: &BodySource{Type: "writer", WriterID: -1}}, ErrUnknownHandle},
+	} {
+		req := tc.req
+		req.Method, req.URL, req.Headers = "POST", "http://127.0.0.1:1", map[string]string{"User-Agent": "test"}
+		_, err := Do(context.Background(), &req)
+		if code := ErrorCode(err); code != tc.code {
+			t.Errorf("%+v: error code = %d (%v), want %d", tc.req.BodySource, code, err, tc.code)
+		}
+	}
+}
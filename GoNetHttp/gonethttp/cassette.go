@@ -0,0 +1,342 @@
+// cassette.go
+package gonethttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 录制/回放模式
+const (
+	cassetteModeOff    = "off"    // 关闭，直接访问网络
+	cassetteModeRecord = "record" // 录制：真实请求后把请求/响应追加写入文件
+	cassetteModeReplay = "replay" // 回放：从文件中查找匹配记录，不访问网络
+)
+
+// CassetteConfig 录制/回放配置（SetCassette的参数）
+//
+// 示例：
+//
+//	{"mode": "replay", "path": "cassette.jsonl", "match_on": ["method", "url", "body"],
+//	 "match_headers": ["X-Api-Key"], "strict": true}
+type CassetteConfig struct {
+	Mode         string   `json:"mode"`          // off/record/replay
+	Path         string   `json:"path"`          // JSONL文件路径，每行一条记录
+	MatchOn      []string `json:"match_on"`      // 匹配字段：method/url/body，默认method+url
+	MatchHeaders []string `json:"match_headers"` // 额外参与匹配的请求头（名称不区分大小写）
+	Strict       bool     `json:"strict"`        // 严格模式：回放未命中时报错而不是访问网络
+}
+
+// cassetteEntry 文件中的单条记录
+// Result与Error二选一，回放时原样返回
+type cassetteEntry struct {
+	Request    *Request  `json:"request"`
+	Result     *Response `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	RecordedAt string    `json:"recorded_at"`
+}
+
+// cassette 录制/回放状态
+type cassette struct {
+	cfg     CassetteConfig
+	mu      sync.Mutex
+	entries []*cassetteEntry // 回放模式下加载的记录
+	played  []bool           // 记录是否已被回放过
+}
+
+var (
+	cassetteMu      sync.RWMutex
+	currentCassette *cassette // 为nil表示未启用
+)
+
+// SetCassette 配置全局录制/回放模式，mode为off或空时关闭
+// 返回值: 回放模式下加载的记录数
+func SetCassette(cfg CassetteConfig) (int, error) {
+	c, err := newCassette(cfg)
+	if err != nil {
+		return 0, err
+	}
+	cassetteMu.Lock()
+	currentCassette = c
+	cassetteMu.Unlock()
+	if c == nil {
+		return 0, nil
+	}
+	return len(c.entries), nil
+}
+
+// activeCassette 返回当前启用的cassette，未启用时返回nil
+func activeCassette() *cassette {
+	cassetteMu.RLock()
+	defer cassetteMu.RUnlock()
+	return currentCassette
+}
+
+// newCassette 根据配置创建cassette
+// 回放模式下会一次性加载全部记录；关闭模式返回nil
+func newCassette(cfg CassetteConfig) (*cassette, error) {
+	cfg.Mode = strings.ToLower(cfg.Mode)
+	if cfg.Mode == "" || cfg.Mode == cassetteModeOff {
+		return nil, nil
+	}
+	if cfg.Mode != cassetteModeRecord && cfg.Mode != cassetteModeReplay {
+		return nil, fmt.Errorf("cassette配置错误: 未知模式 %s", cfg.Mode)
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("cassette配置错误: 缺少文件路径")
+	}
+	if len(cfg.MatchOn) == 0 {
+		cfg.MatchOn = []string{"method", "url"}
+	}
+	for _, field := range cfg.MatchOn {
+		switch field {
+		case "method", "url", "body":
+		default:
+			return nil, fmt.Errorf("cassette配置错误: 不支持的匹配字段 %s", field)
+		}
+	}
+	c := &cassette{cfg: cfg}
+	if cfg.Mode == cassetteModeReplay {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Mode == cassetteModeRecord {
+		// 提前确认文件可写，避免录制时才发现
+		f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("cassette配置错误: %v", err)
+		}
+		f.Close()
+	}
+	return c, nil
+}
+
+// load 读取JSONL文件中的全部记录
+func (c *cassette) load() error {
+	f, err := os.Open(c.cfg.Path)
+	if err != nil {
+		return fmt.Errorf("cassette文件读取失败: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	// 单条记录包含完整响应体，放宽行长度限制
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry cassetteEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return fmt.Errorf("cassette文件读取失败: 第%d行格式错误: %v", line, err)
+		}
+		if entry.Request == nil {
+			return fmt.Errorf("cassette文件读取失败: 第%d行缺少request字段", line)
+		}
+		// 兼容去除凭据之前录制的文件，匹配时双方都使用去除凭据后的形式
+		entry.Request = redactRequest(entry.Request)
+		c.entries = append(c.entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cassette文件读取失败: %v", err)
+	}
+	c.played = make([]bool, len(c.entries))
+	return nil
+}
+
+// handle 按模式处理请求
+// 参数 next: 实际发起网络请求的函数
+func (c *cassette) handle(spec *Request, next func(*Request) (*Response, error)) (*Response, error) {
+	if c.cfg.Mode == cassetteModeReplay {
+		if entry := c.find(redactRequest(spec)); entry != nil {
+			if entry.Error != "" {
+				return nil, errors.New(entry.Error)
+			}
+			// 复制一份再标记来源，避免并发回放时修改共享记录
+			var result Response
+			if entry.Result != nil {
+				result = *entry.Result
+			}
+			result.Cassette = cassetteModeReplay
+			return &result, nil
+		}
+		if c.cfg.Strict {
+			return nil, fmt.Errorf("回放记录未命中: %s %s", spec.Method, spec.URL)
+		}
+		return next(spec)
+	}
+	result, err := next(spec)
+	if errRecord := c.record(spec, result, err); errRecord != nil && err == nil {
+		// 请求本身成功：仍返回响应，同时报告录制失败（error_code 4007）
+		return result, fmt.Errorf("cassette文件写入失败: %v", errRecord)
+	}
+	return result, err
+}
+
+// find 查找匹配的记录
+// 优先返回未回放过的记录，保证同一请求多次录制时按顺序回放；
+// 全部回放过后重复使用最后一条匹配记录
+func (c *cassette) find(spec *Request) *cassetteEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := -1
+	for i, entry := range c.entries {
+		if !c.matches(entry.Request, spec) {
+			continue
+		}
+		if !c.played[i] {
+			c.played[i] = true
+			return entry
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.entries[last]
+	}
+	return nil
+}
+
+// matches 按配置的字段比较两次请求
+func (c *cassette) matches(recorded, spec *Request) bool {
+	for _, field := range c.cfg.MatchOn {
+		switch field {
+		case "method":
+			if !strings.EqualFold(recorded.Method, spec.Method) {
+				return false
+			}
+		case "url":
+			if recorded.URL != spec.URL {
+				return false
+			}
+		case "body":
+			if recorded.Body != spec.Body {
+				return false
+			}
+		}
+	}
+	for _, name := range c.cfg.MatchHeaders {
+		if lookupHeader(recorded.Headers, name) != lookupHeader(spec.Headers, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// record 将一次请求及其结果追加写入文件，请求中的凭据在写入前去除
+func (c *cassette) record(spec *Request, result *Response, err error) error {
+	entry := cassetteEntry{
+		Request:    redactRequest(spec),
+		Result:     result,
+		RecordedAt: time.Now().Format(time.RFC3339),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, errJSON := json.Marshal(entry)
+	if errJSON != nil {
+		return errJSON
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, errOpen := os.OpenFile(c.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if errOpen != nil {
+		return errOpen
+	}
+	if _, errWrite := f.Write(append(line, '\n')); errWrite != nil {
+		f.Close()
+		return errWrite
+	}
+	return f.Close()
+}
+
+// redactedValue 录制文件中替换凭据的占位值
+const redactedValue = "[REDACTED]"
+
+// credentialHeaders 携带凭据的请求头，录制时取值替换为占位值
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// isSignatureHeader 判断是否为签名产生的请求头（X-Amz-*、HMAC签名与时间戳头）
+// 这些请求头每次请求都不同，录制时直接移除，也不参与回放匹配
+func isSignatureHeader(name string, sign *SignConfig) bool {
+	if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+		return true
+	}
+	if sign == nil || sign.Type != signHMAC {
+		return false
+	}
+	return strings.EqualFold(name, sign.Header) ||
+		(sign.TimestampHeader != "" && strings.EqualFold(name, sign.TimestampHeader))
+}
+
+// redactRequest 返回去除凭据后的请求副本，录制文件可以作为测试数据提交
+// 录制与回放匹配都使用该形式：
+//  1. auth中的密码与令牌、sign中的密钥替换为占位值（保留认证方式、用户名与签名参数）
+//  2. 携带凭据的请求头与cookies字段的取值替换为占位值，签名产生的请求头直接移除
+//  3. 隐去代理地址中的密码
+func redactRequest(spec *Request) *Request {
+	r := *spec
+	if spec.Headers != nil {
+		r.Headers = make(map[string]string, len(spec.Headers))
+		for k, v := range spec.Headers {
+			if isSignatureHeader(k, spec.Sign) {
+				continue
+			}
+			for _, name := range credentialHeaders {
+				if strings.EqualFold(k, name) {
+					v = redactedValue
+					break
+				}
+			}
+			r.Headers[k] = v
+		}
+	}
+	if spec.Cookies != nil {
+		r.Cookies = make(map[string]string, len(spec.Cookies))
+		for name := range spec.Cookies {
+			r.Cookies[name] = redactedValue
+		}
+	}
+	if spec.Auth != nil {
+		auth := *spec.Auth
+		if auth.Password != "" {
+			auth.Password = redactedValue
+		}
+		if auth.Token != "" {
+			auth.Token = redactedValue
+		}
+		r.Auth = &auth
+	}
+	if spec.Sign != nil {
+		sign := *spec.Sign
+		for _, secret := range []*string{&sign.AccessKey, &sign.SecretKey, &sign.SessionToken, &sign.Secret} {
+			if *secret != "" {
+				*secret = redactedValue
+			}
+		}
+		r.Sign = &sign
+	}
+	if u, err := url.Parse(spec.Proxy); err == nil && u.User != nil {
+		r.Proxy = u.Redacted()
+	}
+	return &r
+}
+
+// lookupHeader 不区分大小写地读取请求头
+func lookupHeader(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
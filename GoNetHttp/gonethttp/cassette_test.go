@@ -0,0 +1,159 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useCassette 在测试期间启用录制/回放，结束后关闭
+func useCassette(t *testing.T, cfg CassetteConfig) {
+	t.Helper()
+	if _, err := SetCassette(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetCassette(CassetteConfig{}) })
+}
+
+func TestCassetteRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "recorded")
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	newRequest := func() *Request {
+		return &Request{
+			Method: "GET",
+			URL:    srv.URL + "/private",
+			Headers: map[string]string{
+				"User-Agent":    "test",
+				"Authorization": "Bearer header-token",
+				"Cookie":        "sid=cookie-header",
+			},
+			Cookies: map[string]string{"session": "cookie-field"},
+			Auth:    &AuthConfig{Type: "basic", Username: "alice", Password: "basic-password"},
+		}
+	}
+
+	useCassette(t, CassetteConfig{Mode: "record", Path: path})
+	if _, err := Do(context.Background(), newRequest()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"header-token", "cookie-header", "cookie-field", "basic-password"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"username":"alice"`) {
+		t.Errorf("cassette lost auth username: %s", data)
+	}
+
+	srv.Close()
+	useCassette(t, CassetteConfig{Mode: "replay", Path: path, MatchHeaders: []string{"Authorization"}, Strict: true})
+	res, err := Do(context.Background(), newRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Cassette != "replay" || res.Text != "recorded" {
+		t.Fatalf("got cassette=%q body=%q, want replayed response", res.Cassette, res.Text)
+	}
+}
+
+func TestCassetteRedactsSigning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "signed")
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	newRequest := func(date string) *Request {
+		return &Request{
+			Method: "GET",
+			URL:    srv.URL + "/bucket/key",
+			Headers: map[string]string{
+				"User-Agent": "test",
+				"X-Amz-Date": date,
+			},
+			Sign: &SignConfig{Type: signAWSv4, AccessKey: "AKIDEXAMPLE", SecretKey: "aws-secret-key", SessionToken: "aws-session-token", Service: "s3"},
+		}
+	}
+
+	useCassette(t, CassetteConfig{Mode: "record", Path: path})
+	if _, err := Do(context.Background(), newRequest("20240101T000000Z")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"AKIDEXAMPLE", "aws-secret-key", "aws-session-token", "20240101T000000Z"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q: %s", secret, data)
+		}
+	}
+
+	// 日期变化后签名请求头不同，仍应命中
+	srv.Close()
+	useCassette(t, CassetteConfig{Mode: "replay", Path: path, MatchHeaders: []string{"X-Amz-Date"}, Strict: true})
+	res, err := Do(context.Background(), newRequest("20250606T120000Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Cassette != "replay" || res.Text != "signed" {
+		t.Fatalf("got cassette=%q body=%q, want replayed response", res.Cassette, res.Text)
+	}
+}
+
+func TestRedactRequestHMAC(t *testing.T) {
+	spec := &Request{
+		Headers: map[string]string{"X-Signature": "abc", "X-Timestamp": "1700000000", "X-Trace": "keep"},
+		Sign:    &SignConfig{Type: signHMAC, Secret: "hmac-secret", Header: "X-Signature", TimestampHeader: "X-Timestamp"},
+	}
+	r := redactRequest(spec)
+	if r.Sign.Secret != redactedValue || spec.Sign.Secret != "hmac-secret" {
+		t.Errorf("secret = %q (original %q)", r.Sign.Secret, spec.Sign.Secret)
+	}
+	if len(r.Headers) != 1 || r.Headers["X-Trace"] != "keep" {
+		t.Errorf("headers = %v, want only X-Trace", r.Headers)
+	}
+}
+
+func TestCassetteRecordWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	if _, err := SetCassette(CassetteConfig{Mode: "record", Path: dir}); ErrorCode(err) != ErrCassetteConfig {
+		t.Fatalf("record into a directory: %v, want code %d", err, ErrCassetteConfig)
+	}
+
+	path := filepath.Join(dir, "cassette.jsonl")
+	useCassette(t, CassetteConfig{Mode: "record", Path: path})
+	// 配置之后文件变得不可写
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrCassetteConfig {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrCassetteConfig)
+	}
+	if res == nil || res.Text != "ok" {
+		t.Fatalf("response = %+v, want the successful response alongside the error", res)
+	}
+	if envelope := Envelope(res, err); envelope["result"] != res || envelope["error_code"] != ErrCassetteConfig {
+		t.Fatalf("envelope = %v", envelope)
+	}
+}
@@ -0,0 +1,273 @@
+// client.go
+package gonethttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// ClientConfig 客户端配置（C接口NewClient的JSON参数）
+type ClientConfig struct {
+	Limits          *LimitConfig       `json:"limits"`             // 限流与并发上限
+	HeaderProfile   string             `json:"header_profile"`     // 默认请求头配置，请求未指定时使用
+	UserAgentPolicy string             `json:"user_agent_policy"`  // 默认User-Agent策略，请求未指定时使用
+	DNS             *DNSConfig         `json:"dns"`                // 自定义DNS解析
+	Bind            *BindConfig        `json:"bind"`               // 本地出口地址绑定与轮换
+	Destination     *DestinationConfig `json:"destination_policy"` // 目标地址安全策略，未配置时使用全局策略
+	OAuth           *OAuthConfig       `json:"oauth"`              // OAuth2令牌，请求未指定auth时注入Bearer
+	Sign            *SignConfig        `json:"sign"`               // 默认请求签名，请求未指定时使用
+	Cache           *CacheConfig       `json:"cache"`              // HTTP缓存
+	CookieJar       bool               `json:"cookie_jar"`         // 启用Cookie罐：保存响应的Set-Cookie并在后续请求中发送，可通过ImportCookies导入
+}
+
+// Client 客户端
+// 同一客户端上的请求共享连接池、配置与状态（限流、DNS缓存、Cookie罐等）；
+// 创建后登记在进程内，请求描述可通过client字段按ID引用，不再使用时调用Close释放
+type Client struct {
+	id       int64
+	cfg      ClientConfig
+	limits   *hostLimits
+	resolver *resolver
+	binder   *binder
+	guard    *destinationGuard
+	digest   *digestCache // Digest认证质询与nonce计数，在句柄内的请求间共享
+	tokens   *tokenSource
+	cache    cacheStore
+	jar      *cookiejar.Jar
+}
+
+var (
+	clientSeq int64 // 客户端ID自增序列
+	clientsMu sync.RWMutex
+	clients   = map[int64]*Client{}
+)
+
+// NewClient 根据配置创建客户端并登记
+func NewClient(cfg ClientConfig) (*Client, error) {
+	c, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	clientsMu.Lock()
+	clients[c.id] = c
+	clientsMu.Unlock()
+	return c, nil
+}
+
+// LookupClient 按ID查找已登记的客户端
+func LookupClient(id int64) (*Client, error) {
+	c, err := lookupClient(id)
+	if err == nil && c == nil {
+		err = fmt.Errorf("未知的客户端ID: %d", id)
+	}
+	return c, err
+}
+
+// ID 客户端ID，即请求描述中client字段的取值
+func (c *Client) ID() int64 {
+	return c.id
+}
+
+// Close 注销客户端并关闭其空闲连接，之后引用该客户端的请求返回错误
+func (c *Client) Close() error {
+	clientsMu.Lock()
+	_, ok := clients[c.id]
+	delete(clients, c.id)
+	clientsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("未知的客户端ID: %d", c.id)
+	}
+	closeTransports(c.id)
+	return nil
+}
+
+// newClient 根据配置创建客户端
+func newClient(cfg ClientConfig) (*Client, error) {
+	limits, err := newHostLimits(cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResolver(cfg.DNS)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newBinder(cfg.Bind)
+	if err != nil {
+		return nil, err
+	}
+	guard, err := newDestinationGuard(cfg.Destination)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := newTokenSource(cfg.OAuth)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := newCacheStore(cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := newCookieJar(cfg.CookieJar)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		id:       atomic.AddInt64(&clientSeq, 1),
+		cfg:      cfg,
+		limits:   limits,
+		resolver: res,
+		binder:   b,
+		guard:    guard,
+		digest:   newDigestCache(),
+		tokens:   tokens,
+		cache:    cache,
+		jar:      jar,
+	}, nil
+}
+
+// lookupClient 按ID查找客户端，ID为0表示不使用客户端句柄
+func lookupClient(id int64) (*Client, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	c, ok := clients[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的客户端ID: %d", id)
+	}
+	return c, nil
+}
+
+// applyDefaults 用客户端配置填充请求未指定的字段
+func (c *Client) applyDefaults(spec *Request) {
+	if c == nil {
+		return
+	}
+	if spec.HeaderProfile == "" {
+		spec.HeaderProfile = c.cfg.HeaderProfile
+	}
+	if spec.UserAgentPolicy == "" {
+		spec.UserAgentPolicy = c.cfg.UserAgentPolicy
+	}
+	if spec.Sign == nil && c.cfg.Sign != nil {
+		sign := *c.cfg.Sign
+		spec.Sign = &sign
+	}
+}
+
+// authTransport 在传输层外包装认证
+// 请求指定了auth时按auth处理（Digest质询在句柄内共享），否则使用客户端的OAuth2令牌
+// 参数 tokenBase: 访问OAuth2令牌端点的传输层（不含签名与进度统计）
+func (c *Client) authTransport(base, tokenBase http.RoundTripper, auth *AuthConfig, target *url.URL) http.RoundTripper {
+	if c == nil {
+		return newAuthTransport(base, auth, target, nil)
+	}
+	if auth == nil && c.tokens != nil {
+		return &oauthTransport{base: base, tokenBase: tokenBase, tokens: c.tokens, origin: originOf(target)}
+	}
+	return newAuthTransport(base, auth, target, c.digest)
+}
+
+// cacheTransport 在最外层包装HTTP缓存，命中时不经过认证、签名与限流
+// 参数 authenticated: 请求是否携带凭据（认证、OAuth2、签名或Authorization头）
+func (c *Client) cacheTransport(base http.RoundTripper, authenticated bool) http.RoundTripper {
+	if c == nil || c.cache == nil {
+		return base
+	}
+	maxBytes := c.cfg.Cache.MaxEntryBytes
+	if maxBytes <= 0 {
+		maxBytes = 1024 * 1024 * 5 // 与响应体读取上限一致
+	}
+	return &cachingTransport{base: base, store: c.cache, maxBytes: maxBytes, authenticated: authenticated}
+}
+
+// cookieJar 返回客户端的Cookie罐，未启用时返回nil接口
+func (c *Client) cookieJar() http.CookieJar {
+	if c == nil || c.jar == nil {
+		return nil
+	}
+	return c.jar
+}
+
+// destinationGuard 返回生效的目标地址安全策略，客户端未配置时使用全局策略
+func (c *Client) destinationGuard() *destinationGuard {
+	guard, _ := c.policy()
+	return guard
+}
+
+// policy 返回生效的安全策略及全局策略版本，客户端单独配置策略时版本为0
+func (c *Client) policy() (*destinationGuard, uint64) {
+	if c != nil && c.guard != nil {
+		return c.guard, 0
+	}
+	return activeGuard()
+}
+
+// dialer 返回客户端建立TCP连接的函数
+// 配置了DNS时先按客户端解析器解析主机名，配置了本地地址时按目标IP版本选择出口地址，
+// 配置了安全策略时在连接前检查解析出的地址
+// 参数 bind: 请求级的本地地址绑定，优先于客户端配置
+// 参数 guard: 直连目标时的安全策略，连接代理服务器时为nil
+func (c *Client) dialer(bind *binder, guard *destinationGuard) dialFunc {
+	var res *resolver
+	if c != nil {
+		res = c.resolver
+		if bind == nil {
+			bind = c.binder
+		}
+	}
+	if res == nil && bind == nil && guard == nil {
+		return baseDialer.DialContext
+	}
+	if res == nil {
+		// 需要先知道目标IP才能选择出口地址或检查策略，使用不缓存的系统解析
+		res, _ = newResolver(&DNSConfig{})
+	}
+	return res.dialContext(bind, guard)
+}
+
+// targetDialer 按传输参数返回建立TCP连接的函数
+// 直连时在连接前按安全策略检查目标地址；使用代理时拨号对象是代理服务器，目标由代理解析
+func (c *Client) targetDialer(opts transportOptions, guard *destinationGuard) (dialFunc, error) {
+	bind, err := parseLocalAddress(opts.LocalAddress)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Proxy != "" {
+		guard = nil
+	}
+	return c.dialer(bind, guard), nil
+}
+
+// roundTripper 为请求构造传输层
+// 在共享Transport之上依次叠加客户端级别的限流与目标地址检查
+func (c *Client) roundTripper(opts transportOptions) (http.RoundTripper, error) {
+	if c != nil {
+		opts.Client = c.id
+	}
+	// 拨号函数、缓存键与请求检查使用同一份策略
+	guard, generation := c.policy()
+	opts.Policy = generation
+	dial, err := c.targetDialer(opts, guard)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper
+	transport, err = transportFor(opts, dial)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil && c.limits != nil {
+		transport = &limitedTransport{base: transport, limits: c.limits}
+	}
+	if guard != nil {
+		// 在排队之前检查，被拒绝的请求不占用限流名额
+		transport = &guardedTransport{base: transport, guard: guard}
+	}
+	return transport, nil
+}
@@ -0,0 +1,134 @@
+// compress.go
+package gonethttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressEncodings 支持的请求体压缩算法及对应的Content-Encoding
+var compressEncodings = map[string]string{
+	"gzip":    "gzip",
+	"deflate": "deflate", // HTTP的deflate为zlib格式（RFC 1950）
+	"br":      "br",
+	"brotli":  "br",
+	"zstd":    "zstd",
+}
+
+// validateCompress 校验请求描述中的compress字段并规范化为Content-Encoding取值
+func validateCompress(spec *Request) error {
+	if spec.Compress == "" {
+		return nil
+	}
+	encoding, ok := compressEncodings[strings.ToLower(spec.Compress)]
+	if !ok {
+		return fmt.Errorf("压缩配置无效: 不支持的算法 %s（可选gzip、deflate、br、zstd）", spec.Compress)
+	}
+	spec.Compress = encoding
+	return nil
+}
+
+// newCompressWriter 创建对应算法的压缩写入器
+func newCompressWriter(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "deflate":
+		return zlib.NewWriter(w), nil
+	case "br":
+		return brotli.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("压缩配置无效: 不支持的算法 %s", encoding)
+}
+
+// compressRequest 压缩已确定的请求体（表单编码之后）并设置Content-Encoding
+// 内存中的请求体整体压缩并重新计算Content-Length；streaming为true（文件与写入器来源）时边读边压缩，使用chunked传输编码
+func compressRequest(req *http.Request, encoding string, streaming bool) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	req.Header.Set("Content-Encoding", encoding)
+	req.Header.Del("Content-Length")
+	getBody := req.GetBody
+	if !streaming {
+		data, err := compressBytes(req.Body, encoding)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		return nil
+	}
+	req.Body = compressStream(req.Body, encoding)
+	req.ContentLength = -1
+	if getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return compressStream(body, encoding), nil
+		}
+	}
+	return nil
+}
+
+// compressBytes 读取并整体压缩请求体
+func compressBytes(body io.ReadCloser, encoding string) ([]byte, error) {
+	defer body.Close()
+	var buf bytes.Buffer
+	zw, err := newCompressWriter(&buf, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(zw, body); err != nil {
+		return nil, fmt.Errorf("请求体压缩失败: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("请求体压缩失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// compressStream 边读边压缩请求体，关闭返回值时同时关闭原请求体
+func compressStream(body io.ReadCloser, encoding string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		zw, err := newCompressWriter(pw, encoding)
+		if err == nil {
+			if _, err = io.Copy(zw, body); err == nil {
+				err = zw.Close()
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("请求体压缩失败: %v", err)
+		}
+		pw.CloseWithError(err)
+	}()
+	return &compressedBody{PipeReader: pr, src: body}
+}
+
+// compressedBody 压缩后的流式请求体
+type compressedBody struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+// Close 中止压缩协程并关闭原请求体（由传输层在发送结束或失败后调用）
+func (c *compressedBody) Close() error {
+	c.PipeReader.Close()
+	return c.src.Close()
+}
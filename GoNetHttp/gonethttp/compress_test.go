@@ -0,0 +1,71 @@
+package gonethttp
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressHandler 按Content-Encoding解压请求体后原样返回
+func decompressHandler(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "gzip":
+		body, _ = gzip.NewReader(r.Body)
+	case "deflate":
+		body, _ = zlib.NewReader(r.Body)
+	case "br":
+		body = brotli.NewReader(r.Body)
+	case "zstd":
+		body, _ = zstd.NewReader(r.Body)
+	}
+	if body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "%s %d %s", r.Header.Get("Content-Encoding"), r.ContentLength, data)
+}
+
+func TestCompressRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(decompressHandler))
+	defer srv.Close()
+	payload := strings.Repeat("compress me ", 100)
+
+	for _, name := range []string{"gzip", "deflate", "br", "brotli", "zstd"} {
+		t.Run(name, func(t *testing.T) {
+			res, err := Do(context.Background(), &Request{
+				Method:   "POST",
+				URL:      srv.URL,
+				Headers:  map[string]string{"User-Agent": "test"},
+				Body:     payload,
+				Compress: name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := strings.SplitN(res.Text, " ", 3)
+			if res.StatusCode != http.StatusOK || len(fields) != 3 || fields[2] != payload {
+				t.Fatalf("got %d %q", res.StatusCode, res.Text)
+			}
+			// 内存中的请求体整体压缩，Content-Length为压缩后的长度
+			if length, _ := strconv.Atoi(fields[1]); length <= 0 || length >= len(payload) {
+				t.Fatalf("%s: Content-Length = %s, want compressed length", fields[0], fields[1])
+			}
+		})
+	}
+}
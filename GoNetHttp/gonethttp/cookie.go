@@ -0,0 +1,226 @@
+// cookie.go
+package gonethttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cookie导入格式
+const (
+	cookieFormatNetscape = "netscape" // Netscape cookies.txt（curl、wget、浏览器扩展导出）
+	cookieFormatJSON     = "json"     // 浏览器扩展或Playwright/Puppeteer导出的JSON
+)
+
+// ImportCookies 向客户端的Cookie罐导入Cookie，客户端需启用cookie_jar
+// 参数 format: netscape/json，空字符串时按内容自动识别
+// 返回值: 导入的Cookie数（已过期的不计入）
+func (c *Client) ImportCookies(data, format string) (int, error) {
+	if c.jar == nil {
+		return 0, fmt.Errorf("Cookie导入失败: 客户端未启用cookie_jar")
+	}
+	cookies, err := parseCookieImport(data, format)
+	if err != nil {
+		return 0, err
+	}
+	imported := 0
+	now := time.Now()
+	for _, ic := range cookies {
+		if !ic.cookie.Expires.IsZero() && ic.cookie.Expires.Before(now) {
+			continue
+		}
+		c.jar.SetCookies(ic.siteURL(), []*http.Cookie{ic.cookie})
+		imported++
+	}
+	return imported, nil
+}
+
+// newCookieJar 根据配置创建Cookie罐，未启用时返回nil
+func newCookieJar(enabled bool) (*cookiejar.Jar, error) {
+	if !enabled {
+		return nil, nil
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("客户端配置解析失败: %v", err)
+	}
+	return jar, nil
+}
+
+// importedCookie 导入的Cookie及其所属主机
+// 域Cookie（对子域名生效）保留Domain属性；仅主机名生效的Cookie不设置Domain，
+// Cookie罐只在设置它的主机上返回没有Domain属性的Cookie
+type importedCookie struct {
+	cookie *http.Cookie
+	host   string
+}
+
+func newImportedCookie(ck *http.Cookie, domain string, includeSubdomains bool) importedCookie {
+	if includeSubdomains {
+		ck.Domain = domain
+	}
+	return importedCookie{cookie: ck, host: strings.TrimPrefix(domain, ".")}
+}
+
+// siteURL 构造Cookie所属站点的URL，供Cookie罐按域名与路径匹配
+func (ic importedCookie) siteURL() *url.URL {
+	scheme := "http"
+	if ic.cookie.Secure {
+		scheme = "https"
+	}
+	path := ic.cookie.Path
+	if path == "" {
+		path = "/"
+	}
+	return &url.URL{Scheme: scheme, Host: ic.host, Path: path}
+}
+
+// parseCookieImport 解析导入的Cookie，format为空时按内容识别
+func parseCookieImport(data, format string) ([]importedCookie, error) {
+	if format == "" {
+		format = cookieFormatNetscape
+		if trimmed := strings.TrimSpace(data); strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+			format = cookieFormatJSON
+		}
+	}
+	switch strings.ToLower(format) {
+	case cookieFormatNetscape:
+		return parseNetscapeCookies(data)
+	case cookieFormatJSON:
+		return parseJSONCookies(data)
+	}
+	return nil, fmt.Errorf("Cookie导入失败: 未知的格式 %s", format)
+}
+
+// parseNetscapeCookies 解析Netscape cookies.txt
+// 每行7个以Tab分隔的字段：domain、include_subdomains、path、secure、expires、name、value
+// 以#HttpOnly_开头的行表示HttpOnly Cookie，其余#开头的行为注释
+func parseNetscapeCookies(data string) ([]importedCookie, error) {
+	var cookies []importedCookie
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line, httpOnly = rest, true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("Cookie导入失败: 第%d行字段数不足", lineNo)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Cookie导入失败: 第%d行过期时间无效 %q", lineNo, fields[4])
+		}
+		ck := &http.Cookie{
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			ck.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, newImportedCookie(ck, fields[0], strings.EqualFold(fields[1], "TRUE")))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Cookie导入失败: %v", err)
+	}
+	return cookies, nil
+}
+
+// jsonCookie 浏览器导出的Cookie
+// 兼容EditThisCookie/Cookie-Editor（expirationDate、hostOnly、sameSite为no_restriction等）
+// 与Playwright/Puppeteer（expires为-1表示会话Cookie，sameSite为Lax/Strict/None）
+type jsonCookie struct {
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`
+	Domain         string   `json:"domain"`
+	Path           string   `json:"path"`
+	Secure         bool     `json:"secure"`
+	HttpOnly       bool     `json:"httpOnly"`
+	SameSite       string   `json:"sameSite"`
+	HostOnly       bool     `json:"hostOnly"`
+	Session        bool     `json:"session"`
+	ExpirationDate *float64 `json:"expirationDate"`
+	Expires        *float64 `json:"expires"`
+}
+
+// parseJSONCookies 解析JSON数组，或带cookies字段的对象（Playwright storage state）
+func parseJSONCookies(data string) ([]importedCookie, error) {
+	var list []jsonCookie
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		var state struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if errState := json.Unmarshal([]byte(data), &state); errState != nil {
+			return nil, fmt.Errorf("Cookie导入失败: %v", err)
+		}
+		list = state.Cookies
+	}
+	cookies := make([]importedCookie, 0, len(list))
+	for i, jc := range list {
+		if jc.Name == "" || jc.Domain == "" {
+			return nil, fmt.Errorf("Cookie导入失败: 第%d条缺少name或domain", i+1)
+		}
+		ck := &http.Cookie{
+			Name:     jc.Name,
+			Value:    jc.Value,
+			Path:     jc.Path,
+			Secure:   jc.Secure,
+			HttpOnly: jc.HttpOnly,
+			SameSite: parseSameSite(jc.SameSite),
+		}
+		expires := jc.ExpirationDate
+		if expires == nil {
+			expires = jc.Expires
+		}
+		if !jc.Session && expires != nil && *expires > 0 {
+			sec, frac := math.Modf(*expires)
+			ck.Expires = time.Unix(int64(sec), int64(frac*1e9))
+		}
+		cookies = append(cookies, newImportedCookie(ck, jc.Domain, !jc.HostOnly))
+	}
+	return cookies, nil
+}
+
+// parseSameSite 转换浏览器导出的sameSite取值
+func parseSameSite(v string) http.SameSite {
+	switch strings.ToLower(v) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none", "no_restriction":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteDefaultMode
+}
+
+// sameSiteName SameSite属性的字符串形式，未设置时为空
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
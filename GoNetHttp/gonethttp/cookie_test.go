@@ -0,0 +1,107 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCookieJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		var names []string
+		for _, ck := range r.Cookies() {
+			names = append(names, ck.Name+"="+ck.Value)
+		}
+		fmt.Fprint(w, names)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientConfig{CookieJar: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// 重定向的中间响应设置的Cookie在下一跳即生效
+	res, err := c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL + "/login", Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "[session=abc]" {
+		t.Fatalf("after redirect: cookies = %s", res.Text)
+	}
+	res, err = c.Do(context.Background(), &Request{
+		Method:  "GET",
+		URL:     srv.URL + "/home",
+		Headers: map[string]string{"User-Agent": "test"},
+		Cookies: map[string]string{"extra": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "[extra=1 session=abc]" {
+		t.Fatalf("next request: cookies = %s", res.Text)
+	}
+
+	// 未启用Cookie罐的客户端不保存Cookie
+	plain, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	res, err = plain.Do(context.Background(), &Request{Method: "GET", URL: srv.URL + "/login", Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "[]" {
+		t.Fatalf("without jar: cookies = %s", res.Text)
+	}
+}
+
+func TestImportCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		for _, ck := range r.Cookies() {
+			names = append(names, ck.Name+"="+ck.Value)
+		}
+		fmt.Fprint(w, names)
+	}))
+	defer srv.Close()
+	c, err := NewClient(ClientConfig{CookieJar: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	netscape := "# Netscape HTTP Cookie File\n" +
+		"127.0.0.1\tFALSE\t/\tFALSE\t0\tsid\tn1\n" +
+		"127.0.0.1\tFALSE\t/\tFALSE\t1\texpired\tx\n"
+	if n, err := c.ImportCookies(netscape, ""); err != nil || n != 1 {
+		t.Fatalf("netscape import = %d, %v; want 1 (expired skipped)", n, err)
+	}
+	if n, err := c.ImportCookies(`[{"name": "pref", "value": "j1", "domain": "127.0.0.1", "path": "/"}]`, "json"); err != nil || n != 1 {
+		t.Fatalf("json import = %d, %v; want 1", n, err)
+	}
+	res, err := c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "[pref=j1 sid=n1]" && res.Text != "[sid=n1 pref=j1]" {
+		t.Fatalf("cookies = %s", res.Text)
+	}
+
+	plain, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if _, err := plain.ImportCookies(netscape, ""); ErrorCode(err) != ErrCookieImport {
+		t.Fatalf("import without jar: %v, want code %d", err, ErrCookieImport)
+	}
+}
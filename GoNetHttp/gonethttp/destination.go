@@ -0,0 +1,249 @@
+// destination.go
+package gonethttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// privateNets 默认拒绝的地址段：私有、回环、链路本地（含云元数据169.254.169.254）、
+// 运营商NAT、未指定、组播与保留地址
+var privateNets = mustParseCIDRs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15",
+	"224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+// DestinationConfig 目标地址安全策略配置
+// IP规则在解析主机名之后、建立连接之前对实际连接的地址检查，DNS重绑定无法绕过；
+// 使用代理时目标主机由代理解析，只能检查IP字面量与主机名规则
+//
+// IP判定顺序：deny_cidrs → allow_cidrs → 默认拒绝私有地址（allow_private为true时放行）
+//
+// 示例：
+//
+//	{"deny_cidrs": ["203.0.113.0/24"], "allow_cidrs": ["10.1.2.0/24"],
+//	 "deny_hosts": ["*.internal.example.com"], "ports": [80, 443], "schemes": ["https"]}
+type DestinationConfig struct {
+	AllowPrivate bool     `json:"allow_private"` // 是否允许私有/回环/链路本地等地址
+	AllowCIDRs   []string `json:"allow_cidrs"`   // 放行的地址段，优先于私有地址限制
+	DenyCIDRs    []string `json:"deny_cidrs"`    // 拒绝的地址段，优先级最高
+	AllowHosts   []string `json:"allow_hosts"`   // 主机名通配符白名单，非空时只允许匹配的主机
+	DenyHosts    []string `json:"deny_hosts"`    // 主机名通配符黑名单
+	Ports        []int    `json:"ports"`         // 允许的端口，空表示不限制
+	Schemes      []string `json:"schemes"`       // 允许的协议，默认http与https
+}
+
+// destinationGuard 按配置检查请求目标
+type destinationGuard struct {
+	cfg        DestinationConfig
+	allowNets  []*net.IPNet
+	denyNets   []*net.IPNet
+	allowPorts map[int]bool
+	schemes    map[string]bool
+}
+
+var (
+	globalGuardMu  sync.RWMutex
+	globalGuard    *destinationGuard // 未使用客户端句柄或客户端未单独配置时生效
+	globalGuardGen uint64            // 全局策略版本，每次设置递增，作为传输层缓存键的一部分
+)
+
+// SetDestinationPolicy 设置全局目标地址安全策略，cfg为nil时关闭
+// 未使用客户端或客户端未单独配置destination_policy的请求按此策略检查
+func SetDestinationPolicy(cfg *DestinationConfig) error {
+	guard, err := newDestinationGuard(cfg)
+	if err != nil {
+		return err
+	}
+	globalGuardMu.Lock()
+	globalGuard = guard
+	globalGuardGen++
+	globalGuardMu.Unlock()
+	// 已缓存的传输层持有旧策略的拨号函数，需要重建；
+	// 与设置并发的请求按旧版本缓存的传输层不会被新版本的请求取用
+	resetTransports()
+	return nil
+}
+
+// activeGuard 返回当前的全局策略及其版本
+func activeGuard() (*destinationGuard, uint64) {
+	globalGuardMu.RLock()
+	defer globalGuardMu.RUnlock()
+	return globalGuard, globalGuardGen
+}
+
+// newDestinationGuard 根据配置创建策略，未配置时返回nil
+func newDestinationGuard(cfg *DestinationConfig) (*destinationGuard, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	g := &destinationGuard{cfg: *cfg, allowPorts: map[int]bool{}, schemes: map[string]bool{}}
+	for _, list := range []struct {
+		cidrs []string
+		nets  *[]*net.IPNet
+	}{{cfg.AllowCIDRs, &g.allowNets}, {cfg.DenyCIDRs, &g.denyNets}} {
+		for _, cidr := range list.cidrs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("目标策略配置错误: 无效的地址段 %q", cidr)
+			}
+			*list.nets = append(*list.nets, ipNet)
+		}
+	}
+	for _, pattern := range append(append([]string{}, cfg.AllowHosts...), cfg.DenyHosts...) {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("目标策略配置错误: 无效的主机通配符 %q", pattern)
+		}
+	}
+	for _, port := range cfg.Ports {
+		if port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("目标策略配置错误: 无效的端口 %d", port)
+		}
+		g.allowPorts[port] = true
+	}
+	schemes := cfg.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme := range schemes {
+		g.schemes[strings.ToLower(scheme)] = true
+	}
+	return g, nil
+}
+
+// checkRequest 检查请求（或重定向的某一跳）的协议、主机名与端口
+// 目标为IP字面量时同时检查IP规则
+func (g *destinationGuard) checkRequest(u *urlParts) error {
+	if g == nil {
+		return nil
+	}
+	if !g.schemes[u.scheme] {
+		return fmt.Errorf("目标地址被安全策略拒绝: 不允许的协议 %s", u.scheme)
+	}
+	if err := g.checkPort(u.port); err != nil {
+		return err
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.host, "."))
+	if ip := net.ParseIP(host); ip != nil {
+		return g.checkIP(ip)
+	}
+	if matchHost(g.cfg.DenyHosts, host) {
+		return fmt.Errorf("目标地址被安全策略拒绝: 主机 %s 在黑名单中", host)
+	}
+	if len(g.cfg.AllowHosts) > 0 && !matchHost(g.cfg.AllowHosts, host) {
+		return fmt.Errorf("目标地址被安全策略拒绝: 主机 %s 不在白名单中", host)
+	}
+	// 代理会把localhost解析为代理自身的回环地址
+	if !g.cfg.AllowPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost")) {
+		return fmt.Errorf("目标地址被安全策略拒绝: 主机 %s 指向回环地址", host)
+	}
+	return nil
+}
+
+// checkDial 在建立连接前检查解析出的IP与端口
+func (g *destinationGuard) checkDial(ip net.IP, port string) error {
+	if g == nil {
+		return nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("目标地址被安全策略拒绝: 无效的端口 %s", port)
+	}
+	if err := g.checkPort(n); err != nil {
+		return err
+	}
+	return g.checkIP(ip)
+}
+
+func (g *destinationGuard) checkPort(port int) error {
+	if len(g.allowPorts) > 0 && !g.allowPorts[port] {
+		return fmt.Errorf("目标地址被安全策略拒绝: 不允许的端口 %d", port)
+	}
+	return nil
+}
+
+func (g *destinationGuard) checkIP(ip net.IP) error {
+	if containsIP(g.denyNets, ip) {
+		return fmt.Errorf("目标地址被安全策略拒绝: %s 在拒绝的地址段中", ip)
+	}
+	if containsIP(g.allowNets, ip) {
+		return nil
+	}
+	if !g.cfg.AllowPrivate && containsIP(privateNets, ip) {
+		return fmt.Errorf("目标地址被安全策略拒绝: %s 为内网或保留地址", ip)
+	}
+	return nil
+}
+
+// urlParts 策略检查所需的URL信息，端口缺省时按协议补全
+type urlParts struct {
+	scheme string
+	host   string
+	port   int
+}
+
+func requestParts(req *http.Request) *urlParts {
+	u := &urlParts{scheme: strings.ToLower(req.URL.Scheme), host: req.URL.Hostname()}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		u.port = port
+	} else if u.scheme == "https" || u.scheme == "wss" {
+		u.port = 443
+	} else {
+		u.port = 80
+	}
+	return u
+}
+
+// guardedTransport 发送每一跳请求前检查目标，重定向同样经过此处
+type guardedTransport struct {
+	base  http.RoundTripper
+	guard *destinationGuard
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.checkRequest(requestParts(req)); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// matchHost 判断主机名是否匹配任一通配符
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
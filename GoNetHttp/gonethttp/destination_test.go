@@ -0,0 +1,141 @@
+package gonethttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestDestinationPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for _, tc := range []struct {
+		name    string
+		policy  DestinationConfig
+		url     string
+		blocked bool
+	}{
+		{"private ip", DestinationConfig{}, srv.URL, true},
+		{"localhost", DestinationConfig{}, "http://localhost:" + port, true},
+		{"allow private", DestinationConfig{AllowPrivate: true}, srv.URL, false},
+		{"allow cidr", DestinationConfig{AllowCIDRs: []string{"127.0.0.0/8"}}, srv.URL, false},
+		{"deny beats allow", DestinationConfig{AllowPrivate: true, DenyCIDRs: []string{"127.0.0.1/32"}}, srv.URL, true},
+		{"port", DestinationConfig{AllowPrivate: true, Ports: []int{443}}, srv.URL, true},
+		{"scheme", DestinationConfig{AllowPrivate: true, Schemes: []string{"https"}}, srv.URL, true},
+		{"deny host", DestinationConfig{AllowPrivate: true, DenyHosts: []string{"*.test"}}, "http://api.test:" + port, true},
+		// 主机名规则放行，解析出的回环地址在连接前被拒绝（防DNS重绑定）
+		{"resolved address", DestinationConfig{}, "http://api.test:" + port, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClient(ClientConfig{
+				Destination: &tc.policy,
+				DNS:         &DNSConfig{Hosts: map[string]string{"api.test": "127.0.0.1"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			_, err = c.Do(context.Background(), &Request{Method: "GET", URL: tc.url, Headers: map[string]string{"User-Agent": "test"}})
+			if blocked := ErrorCode(err) == ErrDestBlocked; blocked != tc.blocked {
+				t.Fatalf("blocked = %v (%v), want %v", blocked, err, tc.blocked)
+			}
+		})
+	}
+}
+
+func TestDestinationPolicyRedirect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	first, _ := strconv.Atoi(port)
+
+	// 只放行第一跳的端口，重定向到其他端口时同样检查
+	c, err := NewClient(ClientConfig{Destination: &DestinationConfig{AllowPrivate: true, Ports: []int{first}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_, err = c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrDestBlocked {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrDestBlocked)
+	}
+}
+
+func TestDestinationPolicyChangeDuringRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	target := "http://api.test:" + port
+	defer SetDestinationPolicy(nil)
+
+	// 客户端未单独配置策略，使用全局策略；主机名放行，解析出的回环地址只在拨号时检查
+	c, err := NewClient(ClientConfig{DNS: &DNSConfig{Hosts: map[string]string{"api.test": "127.0.0.1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	get := func() error {
+		_, err := c.Do(context.Background(), &Request{Method: "GET", URL: target, Headers: map[string]string{"User-Agent": "test"}})
+		return err
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					get()
+				}
+			}
+		}()
+	}
+	for i := range 50 {
+		if i%2 == 0 {
+			SetDestinationPolicy(nil)
+		} else {
+			SetDestinationPolicy(&DestinationConfig{})
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	// 模拟与设置并发的请求在重置之后按旧策略放回传输层
+	_, generation := activeGuard()
+	if err := SetDestinationPolicy(&DestinationConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transportFor(transportOptions{Client: c.id, Policy: generation}, baseDialer.DialContext); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := get(); ErrorCode(err) != ErrDestBlocked {
+			t.Fatalf("error = %v, want code %d after the policy change", err, ErrDestBlocked)
+		}
+	}
+}
+
+func TestDestinationPolicyConfigErrors(t *testing.T) {
+	for _, cfg := range []DestinationConfig{
+		{AllowCIDRs: []string{"10.0.0.0/33"}},
+		{DenyHosts: []string{"[a-"}},
+		{Ports: []int{70000}},
+	} {
+		if _, err := NewClient(ClientConfig{Destination: &cfg}); ErrorCode(err) != ErrClientConfig {
+			t.Errorf("%+v: error = %v, want code %d", cfg, err, ErrClientConfig)
+		}
+	}
+}
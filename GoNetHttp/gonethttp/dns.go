@@ -0,0 +1,407 @@
+// dns.go
+package gonethttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// IP版本偏好
+const (
+	ipPreferIPv4 = "ipv4"      // IPv4优先，失败再尝试IPv6
+	ipPreferIPv6 = "ipv6"      // IPv6优先，失败再尝试IPv4
+	ipOnlyIPv4   = "ipv4_only" // 只使用IPv4
+	ipOnlyIPv6   = "ipv6_only" // 只使用IPv6
+)
+
+// DNSConfig 客户端DNS配置
+//
+// 示例：
+//
+//	{"hosts": {"api.example.com": "10.0.0.8", "www.example.com:443": "10.0.0.9"},
+//	 "servers": ["8.8.8.8:53"], "network": "udp", "cache": true, "prefer": "ipv4"}
+//	{"doh": "https://1.1.1.1/dns-query", "cache": true}
+type DNSConfig struct {
+	Hosts    map[string]string `json:"hosts"`     // 静态解析，键为host或host:port（类似curl --resolve）
+	Servers  []string          `json:"servers"`   // 自定义DNS服务器，格式ip:port，端口缺省为53
+	Network  string            `json:"network"`   // 查询DNS服务器使用的协议：udp（默认，截断时改用tcp）/tcp
+	DoH      string            `json:"doh"`       // DNS-over-HTTPS地址，优先于servers
+	Cache    bool              `json:"cache"`     // 是否启用进程内缓存，按记录TTL过期
+	CacheTTL int               `json:"cache_ttl"` // 系统解析器结果没有TTL信息，缓存秒数，默认60
+	Prefer   string            `json:"prefer"`    // IP版本偏好：ipv4/ipv6/ipv4_only/ipv6_only
+	Timeout  int               `json:"timeout"`   // 单次查询超时秒数，默认5
+}
+
+// resolver 按DNSConfig解析主机名
+type resolver struct {
+	cfg   DNSConfig
+	doh   *http.Client
+	mu    sync.Mutex
+	cache map[string]*dnsCacheEntry
+}
+
+// dnsCacheEntry 缓存的解析结果
+type dnsCacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// newResolver 根据配置创建解析器，未配置时返回nil表示使用系统解析
+// 默认值与服务器端口补全在副本上进行，不修改调用方的配置
+func newResolver(cfg *DNSConfig) (*resolver, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	normalized := *cfg
+	cfg = &normalized
+	// 主机名按lookup的方式规范化（小写、去掉末尾的点），host:port形式保留端口
+	hosts := make(map[string]string, len(cfg.Hosts))
+	for key, value := range cfg.Hosts {
+		if net.ParseIP(value) == nil {
+			return nil, fmt.Errorf("DNS配置错误: %s 的静态解析地址 %q 不是IP", key, value)
+		}
+		if host, port, err := net.SplitHostPort(key); err == nil {
+			hosts[net.JoinHostPort(normalizeHost(host), port)] = value
+		} else {
+			hosts[normalizeHost(key)] = value
+		}
+	}
+	cfg.Hosts = hosts
+	servers := make([]string, 0, len(cfg.Servers))
+	for _, server := range cfg.Servers {
+		addr := server
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		host, _, _ := net.SplitHostPort(addr)
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("DNS配置错误: DNS服务器 %q 不是IP地址", server)
+		}
+		servers = append(servers, addr)
+	}
+	cfg.Servers = servers
+	switch cfg.Network {
+	case "":
+		cfg.Network = "udp"
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("DNS配置错误: 不支持的查询协议 %s", cfg.Network)
+	}
+	switch cfg.Prefer {
+	case "", ipPreferIPv4, ipPreferIPv6, ipOnlyIPv4, ipOnlyIPv6:
+	default:
+		return nil, fmt.Errorf("DNS配置错误: 未知的IP版本偏好 %s", cfg.Prefer)
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 60
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5
+	}
+	r := &resolver{cfg: *cfg, cache: map[string]*dnsCacheEntry{}}
+	if cfg.DoH != "" {
+		if !strings.HasPrefix(cfg.DoH, "https://") {
+			return nil, fmt.Errorf("DNS配置错误: DoH地址必须为https://")
+		}
+		r.doh = &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second}
+	}
+	return r, nil
+}
+
+// normalizeHost 主机名比较时使用的形式：小写且去掉末尾的点
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// lookup 解析主机名，返回按偏好排序的IP列表
+// 解析顺序：IP字面量 → 静态解析 → 缓存 → DoH/自定义DNS服务器/系统解析
+func (r *resolver) lookup(ctx context.Context, host, port string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	host = normalizeHost(host)
+	if ip, ok := r.cfg.Hosts[net.JoinHostPort(host, port)]; ok {
+		return []net.IP{net.ParseIP(ip)}, nil
+	}
+	if ip, ok := r.cfg.Hosts[host]; ok {
+		return []net.IP{net.ParseIP(ip)}, nil
+	}
+	ips, err := r.cachedQuery(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ordered := r.order(ips)
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("DNS解析失败: %s 没有可用地址", host)
+	}
+	return ordered, nil
+}
+
+// cachedQuery 启用缓存时优先返回未过期的结果
+func (r *resolver) cachedQuery(ctx context.Context, host string) ([]net.IP, error) {
+	if r.cfg.Cache {
+		r.mu.Lock()
+		entry, ok := r.cache[host]
+		r.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.ips, nil
+		}
+	}
+	ips, ttl, err := r.query(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.Cache && len(ips) > 0 && ttl > 0 {
+		r.mu.Lock()
+		r.cache[host] = &dnsCacheEntry{ips: ips, expires: time.Now().Add(ttl)}
+		r.mu.Unlock()
+	}
+	return ips, nil
+}
+
+// order 按IP版本偏好过滤并排序
+func (r *resolver) order(ips []net.IP) []net.IP {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	switch r.cfg.Prefer {
+	case ipPreferIPv6:
+		return append(v6, v4...)
+	case ipOnlyIPv4:
+		return v4
+	case ipOnlyIPv6:
+		return v6
+	default:
+		return append(v4, v6...)
+	}
+}
+
+// query 同时查询A与AAAA记录，返回地址与最小TTL
+func (r *resolver) query(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if r.doh == nil && len(r.cfg.Servers) == 0 {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, 0, fmt.Errorf("DNS解析失败: %v", err)
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+		return ips, time.Duration(r.cfg.CacheTTL) * time.Second, nil
+	}
+	var ips []net.IP
+	var minTTL uint32
+	var lastErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		if (qtype == dnsmessage.TypeA && r.cfg.Prefer == ipOnlyIPv6) ||
+			(qtype == dnsmessage.TypeAAAA && r.cfg.Prefer == ipOnlyIPv4) {
+			continue
+		}
+		found, ttl, err := r.exchange(ctx, host, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips = append(ips, found...)
+		if len(found) > 0 && (minTTL == 0 || ttl < minTTL) {
+			minTTL = ttl
+		}
+	}
+	if len(ips) == 0 && lastErr != nil {
+		return nil, 0, lastErr
+	}
+	return ips, time.Duration(minTTL) * time.Second, nil
+}
+
+// exchange 发送单个DNS查询并解析应答
+func (r *resolver) exchange(ctx context.Context, host string, qtype dnsmessage.Type) ([]net.IP, uint32, error) {
+	name, err := dnsmessage.NewName(host + ".")
+	if err != nil {
+		return nil, 0, fmt.Errorf("DNS解析失败: 无效的主机名 %s", host)
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("DNS解析失败: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.cfg.Timeout)*time.Second)
+	defer cancel()
+	var answer []byte
+	if r.doh != nil {
+		answer, err = r.exchangeDoH(ctx, packed)
+	} else {
+		for _, server := range r.cfg.Servers {
+			answer, err = r.exchangeServer(ctx, server, packed)
+			if err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("DNS解析失败: %v", err)
+	}
+	var reply dnsmessage.Message
+	if err := reply.Unpack(answer); err != nil {
+		return nil, 0, fmt.Errorf("DNS解析失败: 应答格式错误: %v", err)
+	}
+	if reply.ID != msg.ID {
+		return nil, 0, fmt.Errorf("DNS解析失败: 应答ID不匹配")
+	}
+	if reply.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, fmt.Errorf("DNS解析失败: %s %s", host, reply.RCode)
+	}
+	var ips []net.IP
+	var minTTL uint32
+	for _, rr := range reply.Answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]))
+		default:
+			continue
+		}
+		if minTTL == 0 || rr.Header.TTL < minTTL {
+			minTTL = rr.Header.TTL
+		}
+	}
+	return ips, minTTL, nil
+}
+
+// exchangeServer 通过UDP或TCP向DNS服务器查询，UDP应答被截断时改用TCP重试
+func (r *resolver) exchangeServer(ctx context.Context, server string, query []byte) ([]byte, error) {
+	if r.cfg.Network == "udp" {
+		answer, err := exchangeUDP(ctx, server, query)
+		if err != nil {
+			return nil, err
+		}
+		// 应答头部第3字节的0x02位为TC（截断）标志
+		if len(answer) < 3 || answer[2]&0x02 == 0 {
+			return answer, nil
+		}
+	}
+	return exchangeTCP(ctx, server, query)
+}
+
+func exchangeUDP(ctx context.Context, server string, query []byte) ([]byte, error) {
+	conn, err := baseDialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func exchangeTCP(ctx context.Context, server string, query []byte) ([]byte, error) {
+	conn, err := baseDialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// TCP查询以2字节长度前缀分帧
+	framed := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	copy(framed[2:], query)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, length)
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// exchangeDoH 按RFC 8484以POST方式发送DNS查询
+func (r *resolver) exchangeDoH(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.DoH, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := r.doh.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH服务器返回 %s", res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 65535))
+}
+
+// dialContext 返回先解析主机名、再依次尝试各个地址建立连接的函数
+// 参数 b: 本地地址绑定，为nil时由系统选择源地址
+// 参数 g: 目标地址安全策略，对解析出的每个地址在连接前检查，为nil时不检查
+func (r *resolver) dialContext(b *binder, g *destinationGuard) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := r.lookup(ctx, host, port)
+		if err != nil {
+			return nil, err
+		}
+		var errs []error
+		for _, ip := range ips {
+			if errGuard := g.checkDial(ip, port); errGuard != nil {
+				errs = append(errs, errGuard)
+				continue
+			}
+			dialer, errBind := b.dialerFor(ip)
+			if errBind != nil {
+				errs = append(errs, errBind)
+				continue
+			}
+			conn, errDial := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if errDial == nil {
+				return conn, nil
+			}
+			errs = append(errs, errDial)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+}
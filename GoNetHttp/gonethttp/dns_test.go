@@ -0,0 +1,145 @@
+package gonethttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS 在本地UDP端口上应答A查询：已知主机返回127.0.0.1，其余返回NXDOMAIN
+func serveDNS(t *testing.T, hosts map[string]bool) (addr string, queries *atomic.Int32) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	queries = &atomic.Int32{}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if msg.Unpack(buf[:n]) != nil || len(msg.Questions) != 1 {
+				continue
+			}
+			queries.Add(1)
+			q := msg.Questions[0]
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: msg.ID, Response: true, RCode: dnsmessage.RCodeSuccess},
+				Questions: msg.Questions,
+			}
+			if !hosts[strings.TrimSuffix(q.Name.String(), ".")] {
+				reply.RCode = dnsmessage.RCodeNameError
+			} else if q.Type == dnsmessage.TypeA {
+				reply.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 300},
+					Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+				}}
+			}
+			packed, _ := reply.Pack()
+			conn.WriteTo(packed, from)
+		}
+	}()
+	return conn.LocalAddr().String(), queries
+}
+
+func TestDNSServers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	dnsAddr, queries := serveDNS(t, map[string]bool{"api.test": true})
+
+	c, err := NewClient(ClientConfig{DNS: &DNSConfig{Servers: []string{dnsAddr}, Cache: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 0; i < 2; i++ {
+		res, err := c.Do(context.Background(), &Request{Method: "GET", URL: "http://api.test:" + port, Headers: map[string]string{"User-Agent": "test"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text != "api.test:"+port || res.RemoteAddr != srv.Listener.Addr().String() {
+			t.Fatalf("got host %q via %s", res.Text, res.RemoteAddr)
+		}
+		// 关闭空闲连接，第二个请求重新拨号以使用DNS缓存
+		closeTransports(c.ID())
+	}
+	// A与AAAA各查询一次，第二个请求命中缓存
+	if n := queries.Load(); n != 2 {
+		t.Fatalf("DNS queries = %d, want 2", n)
+	}
+
+	_, err = c.Do(context.Background(), &Request{Method: "GET", URL: "http://missing.test:" + port, Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrDNSResolve {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrDNSResolve)
+	}
+}
+
+func TestDNSStaticHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// host:port形式优先于host
+	c, err := NewClient(ClientConfig{DNS: &DNSConfig{Hosts: map[string]string{
+		"api.test":         "192.0.2.1",
+		"api.test:" + port: "127.0.0.1",
+		"other.test":       "127.0.0.1",
+		"Mixed.Test.":      "127.0.0.1",
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, host := range []string{"api.test", "other.test", "mixed.test", "MIXED.test"} {
+		res, err := c.Do(context.Background(), &Request{Method: "GET", URL: "http://" + host + ":" + port, Headers: map[string]string{"User-Agent": "test"}, TimeoutMs: 2000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text != host+":"+port {
+			t.Fatalf("got host %q", res.Text)
+		}
+	}
+}
+
+func TestDNSConfigErrors(t *testing.T) {
+	for _, cfg := range []DNSConfig{
+		{Hosts: map[string]string{"a": "not-an-ip"}},
+		{Servers: []string{"dns.example.com"}},
+		{Network: "quic"},
+		{Prefer: "ipv5"},
+		{DoH: "http://1.1.1.1/dns-query"},
+	} {
+		if _, err := NewClient(ClientConfig{DNS: &cfg}); ErrorCode(err) != ErrClientConfig {
+			t.Errorf("%+v: error = %v, want code %d", cfg, err, ErrClientConfig)
+		}
+	}
+}
+
+func TestDNSConfigNotModified(t *testing.T) {
+	servers := []string{"127.0.0.1"}
+	cfg := &DNSConfig{Servers: servers}
+	c, err := NewClient(ClientConfig{DNS: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if servers[0] != "127.0.0.1" || cfg.Network != "" || cfg.CacheTTL != 0 || cfg.Timeout != 0 {
+		t.Fatalf("caller config modified: %+v", *cfg)
+	}
+}
@@ -0,0 +1,164 @@
+// errors.go
+package gonethttp
+
+import "strings"
+
+// 标准错误代码，即C接口返回的error_code
+// 3000系列：重定向相关错误
+// 4000系列：客户端参数错误
+// 5000系列：服务端/网络错误
+const (
+	ErrInvalidMethod    = 4001 // 非法HTTP方法
+	ErrHeaderParse      = 4002 // 请求头解析失败
+	ErrMissingUserAgent = 4003 // 缺少User-Agent
+	ErrProxyConfig      = 4004 // 代理配置错误
+	ErrBodySize         = 4005 // 代理配置错误
+	ErrCassetteMiss     = 4006 // 回放模式下无匹配记录
+	ErrCassetteConfig   = 4007 // 录制/回放配置错误
+	ErrSpecParse        = 4008 // 请求描述解析失败
+	ErrUnknownRequest   = 4009 // 未知的异步请求ID
+	ErrUnknownClient    = 4010 // 未知的客户端ID
+	ErrClientConfig     = 4011 // 客户端配置错误
+	ErrProtocolConfig   = 4012 // 协议选项无效
+	ErrTLSProfile       = 4013 // TLS指纹配置无效
+	ErrHeaderProfile    = 4014 // 请求头配置无效
+	ErrLocalBind        = 4015 // 本地出口地址配置错误或无可用地址
+	ErrDestBlocked      = 4016 // 目标地址被安全策略拒绝
+	ErrAuthConfig       = 4017 // 认证配置无效
+	ErrSignConfig       = 4018 // 签名配置无效
+	ErrUnknownHandle    = 4019 // 未知的流式句柄
+	ErrBodySource       = 4020 // 请求体来源无效
+	ErrCompressConfig   = 4021 // 请求体压缩配置无效
+	ErrCookieImport     = 4022 // Cookie导入失败
+	ErrRedirectExceed   = 3001 // 重定向次数超限
+	ErrNetwork          = 5001 // 网络请求失败
+	ErrReadResponse     = 5002 // 响应读取失败
+	ErrQueueTimeout     = 5003 // 限流排队超时
+	ErrDNSResolve       = 5004 // DNS解析失败
+	ErrOAuthToken       = 5005 // OAuth2令牌获取失败
+	ErrEventStream      = 5006 // 事件流连接或读取失败
+	ErrWebSocket        = 5007 // WebSocket连接、发送或接收失败
+	ErrBodyWrite        = 5008 // 请求体写入失败或被中止
+	ErrUnknown          = 5000 // 未知错误
+)
+
+// ErrorCode 按错误信息归类错误代码，err为nil时返回0
+func ErrorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	// 使用错误信息匹配进行判断
+	switch {
+	case strings.Contains(err.Error(), "无效的HTTP方法"):
+		return ErrInvalidMethod
+	case strings.Contains(err.Error(), "headers参数解析"):
+		return ErrHeaderParse
+	case strings.Contains(err.Error(), "必须提供User-Agent"):
+		return ErrMissingUserAgent
+	case strings.Contains(err.Error(), "代理地址解析失败"):
+		return ErrProxyConfig
+	case strings.Contains(err.Error(), "stopped after"):
+		return ErrRedirectExceed
+	case strings.Contains(err.Error(), "读取响应体失败"),
+		strings.Contains(err.Error(), "stream error"):
+		return ErrReadResponse
+	case strings.Contains(err.Error(), "body size exceeds"):
+		return ErrBodySize
+	case strings.Contains(err.Error(), "请求参数解析失败"):
+		return ErrSpecParse
+	case strings.Contains(err.Error(), "未知的请求ID"):
+		return ErrUnknownRequest
+	case strings.Contains(err.Error(), "未知的客户端ID"):
+		return ErrUnknownClient
+	case strings.Contains(err.Error(), "客户端配置解析失败"),
+		strings.Contains(err.Error(), "限流配置错误"),
+		strings.Contains(err.Error(), "DNS配置错误"),
+		strings.Contains(err.Error(), "目标策略配置错误"),
+		strings.Contains(err.Error(), "OAuth2配置错误"),
+		strings.Contains(err.Error(), "缓存配置错误"):
+		return ErrClientConfig
+	case strings.Contains(err.Error(), "OAuth2令牌获取失败"):
+		return ErrOAuthToken
+	case strings.Contains(err.Error(), "限流排队超时"):
+		return ErrQueueTimeout
+	case strings.Contains(err.Error(), "DNS解析失败"):
+		return ErrDNSResolve
+	case strings.Contains(err.Error(), "协议选项无效"):
+		return ErrProtocolConfig
+	case strings.Contains(err.Error(), "TLS指纹配置无效"),
+		strings.Contains(err.Error(), "TLS指纹解析失败"):
+		return ErrTLSProfile
+	case strings.Contains(err.Error(), "请求头配置无效"):
+		return ErrHeaderProfile
+	case strings.Contains(err.Error(), "本地地址配置错误"),
+		strings.Contains(err.Error(), "本地地址绑定失败"):
+		return ErrLocalBind
+	case strings.Contains(err.Error(), "目标地址被安全策略拒绝"):
+		return ErrDestBlocked
+	case strings.Contains(err.Error(), "认证配置无效"):
+		return ErrAuthConfig
+	case strings.Contains(err.Error(), "签名配置无效"):
+		return ErrSignConfig
+	case strings.Contains(err.Error(), "未知的句柄"):
+		return ErrUnknownHandle
+	case strings.Contains(err.Error(), "事件流连接失败"),
+		strings.Contains(err.Error(), "事件流读取失败"):
+		return ErrEventStream
+	case strings.Contains(err.Error(), "WebSocket连接失败"),
+		strings.Contains(err.Error(), "WebSocket发送失败"),
+		strings.Contains(err.Error(), "WebSocket接收失败"):
+		return ErrWebSocket
+	case strings.Contains(err.Error(), "请求体来源无效"):
+		return ErrBodySource
+	case strings.Contains(err.Error(), "请求体写入失败"),
+		strings.Contains(err.Error(), "请求体写入已中止"):
+		return ErrBodyWrite
+	case strings.Contains(err.Error(), "压缩配置无效"):
+		return ErrCompressConfig
+	case strings.Contains(err.Error(), "Cookie导入失败"):
+		return ErrCookieImport
+	case strings.Contains(err.Error(), "等待响应头超时"):
+		return ErrNetwork
+	case strings.Contains(err.Error(), "回放记录未命中"):
+		return ErrCassetteMiss
+	case strings.Contains(err.Error(), "cassette配置"),
+		strings.Contains(err.Error(), "cassette文件读取失败"),
+		strings.Contains(err.Error(), "cassette文件写入失败"):
+		return ErrCassetteConfig
+	}
+	// 网络相关错误的兜底判断
+	if isNetworkError(err) {
+		return ErrNetwork
+	}
+	return ErrUnknown
+}
+
+// Envelope 构造C接口统一的返回结构
+// - 成功时为 {success:true, error:null, result:data}
+// - 失败时为 {success:false, error:"message", result:data, error_code:num}
+// C导出函数与命令行工具都经由此函数输出，保证同一请求得到相同的JSON
+func Envelope(data interface{}, err error) map[string]interface{} {
+	result := map[string]interface{}{
+		"success": err == nil,
+		"error":   nil,
+		"result":  data,
+	}
+
+	if err != nil {
+		// 错误处理
+		result["error"] = err.Error()
+		// 添加错误代码分类
+		result["error_code"] = ErrorCode(err)
+	}
+	return result
+}
+
+// 辅助函数判断网络错误
+func isNetworkError(err error) bool {
+	_, ok := err.(interface{ Timeout() bool })
+	if ok {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection") ||
+		strings.Contains(err.Error(), "request canceled")
+}
@@ -0,0 +1,79 @@
+package gonethttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{fmt.Errorf("无效的HTTP方法: PUT"), ErrInvalidMethod},
+		{fmt.Errorf("headers参数解析失败"), ErrHeaderParse},
+		{fmt.Errorf("必须提供User-Agent请求头"), ErrMissingUserAgent},
+		{fmt.Errorf("代理地址解析失败: x"), ErrProxyConfig},
+		{fmt.Errorf(`Get "http://a/": stopped after 5 redirects`), ErrRedirectExceed},
+		{fmt.Errorf("读取响应体失败: unexpected EOF"), ErrReadResponse},
+		{fmt.Errorf("stream error: stream ID 1; INTERNAL_ERROR"), ErrReadResponse},
+		{fmt.Errorf("http: request body size exceeds limit"), ErrBodySize},
+		{fmt.Errorf("回放记录未命中: GET http://a/"), ErrCassetteMiss},
+		{fmt.Errorf("cassette配置无效: 未知的模式"), ErrCassetteConfig},
+		{fmt.Errorf("cassette文件读取失败: x"), ErrCassetteConfig},
+		{fmt.Errorf("请求参数解析失败: x"), ErrSpecParse},
+		{fmt.Errorf("未知的请求ID: 1"), ErrUnknownRequest},
+		{fmt.Errorf("未知的客户端ID: 1"), ErrUnknownClient},
+		{fmt.Errorf("限流配置错误: x"), ErrClientConfig},
+		{fmt.Errorf("DNS配置错误: x"), ErrClientConfig},
+		{fmt.Errorf("目标策略配置错误: x"), ErrClientConfig},
+		{fmt.Errorf("OAuth2配置错误: x"), ErrClientConfig},
+		{fmt.Errorf("缓存配置错误: x"), ErrClientConfig},
+		{fmt.Errorf("协议选项无效: x"), ErrProtocolConfig},
+		{fmt.Errorf("TLS指纹配置无效: x"), ErrTLSProfile},
+		{fmt.Errorf("请求头配置无效: x"), ErrHeaderProfile},
+		{fmt.Errorf("本地地址绑定失败: x"), ErrLocalBind},
+		{fmt.Errorf("目标地址被安全策略拒绝: 127.0.0.1"), ErrDestBlocked},
+		{fmt.Errorf("认证配置无效: x"), ErrAuthConfig},
+		{fmt.Errorf("签名配置无效: x"), ErrSignConfig},
+		{fmt.Errorf("未知的句柄: 1"), ErrUnknownHandle},
+		{fmt.Errorf("请求体来源无效: x"), ErrBodySource},
+		{fmt.Errorf("压缩配置无效: x"), ErrCompressConfig},
+		{fmt.Errorf("Cookie导入失败: x"), ErrCookieImport},
+		{fmt.Errorf("限流排队超时: context deadline exceeded"), ErrQueueTimeout},
+		{fmt.Errorf("DNS解析失败: x"), ErrDNSResolve},
+		{fmt.Errorf("OAuth2令牌获取失败: 400"), ErrOAuthToken},
+		{fmt.Errorf("事件流连接失败: 状态码404"), ErrEventStream},
+		{fmt.Errorf("WebSocket连接失败: x"), ErrWebSocket},
+		{fmt.Errorf("WebSocket发送失败: x"), ErrWebSocket},
+		{fmt.Errorf("请求体写入失败: x"), ErrBodyWrite},
+		{fmt.Errorf("请求体写入已中止"), ErrBodyWrite},
+		{fmt.Errorf("等待响应头超时: 100毫秒内未收到响应头"), ErrNetwork},
+		{fmt.Errorf("dial tcp: connection refused"), ErrNetwork},
+		{&net.DNSError{Err: "timeout", IsTimeout: true}, ErrNetwork},
+		{context.DeadlineExceeded, ErrNetwork},
+		{errors.New("something else"), ErrUnknown},
+	} {
+		if got := ErrorCode(tc.err); got != tc.code {
+			t.Errorf("ErrorCode(%v) = %d, want %d", tc.err, got, tc.code)
+		}
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	ok := Envelope("data", nil)
+	if ok["success"] != true || ok["error"] != nil || ok["result"] != "data" {
+		t.Fatalf("success envelope = %v", ok)
+	}
+	if _, has := ok["error_code"]; has {
+		t.Fatalf("success envelope has error_code: %v", ok)
+	}
+	failed := Envelope(nil, fmt.Errorf("未知的客户端ID: 7"))
+	if failed["success"] != false || failed["error"] != "未知的客户端ID: 7" || failed["error_code"] != ErrUnknownClient {
+		t.Fatalf("error envelope = %v", failed)
+	}
+}
@@ -0,0 +1,46 @@
+// Package gonethttp 共享库的请求引擎，可在Go中直接使用，不依赖cgo
+//
+// C导出函数（lib_requests_go）是本包之上的一层薄封装：把JSON请求描述解析为Request，
+// 把结果封装为 {success, error, error_code, result}；句柄与回调只存在于C接口层。
+//
+// 示例：
+//
+//	c, err := gonethttp.NewClient(gonethttp.ClientConfig{CookieJar: true})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	res, err := c.Do(ctx, &gonethttp.Request{
+//		Method:  "GET",
+//		URL:     "https://example.com",
+//		Headers: map[string]string{"User-Agent": "Mozilla/5.0"},
+//	})
+//	if err != nil {
+//		return fmt.Errorf("请求失败(%d): %w", gonethttp.ErrorCode(err), err)
+//	}
+//	fmt.Println(res.StatusCode, res.Text)
+package gonethttp
+
+import "sort"
+
+// Supported 返回请求描述中各枚举字段支持的取值
+// 键：tls_profiles、header_profiles、protocols、compress、auth_types、sign_types
+func Supported() map[string][]string {
+	return map[string][]string{
+		"tls_profiles":    sortedKeys(tlsProfiles),
+		"header_profiles": sortedKeys(headerProfiles),
+		"protocols":       {protocolHTTP1, protocolPreferH2, protocolH2C},
+		"compress":        {"gzip", "deflate", "br", "zstd"},
+		"auth_types":      {authBasic, authBearer, authDigest},
+		"sign_types":      {signAWSv4, signHMAC},
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+// headerprofile.go
+package gonethttp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// User-Agent策略
+const (
+	userAgentRequired = "required" // 必须提供User-Agent（默认），可由请求头配置满足
+	userAgentOptional = "optional" // 不校验User-Agent
+)
+
+// headerProfiles 内置请求头配置
+// 不包含Accept-Encoding：由net/http自动协商gzip并透明解压，
+// 手动声明br/zstd会导致响应体无法解码
+var headerProfiles = map[string]map[string]string{
+	// 桌面版Chrome，与TLS指纹chrome_133版本一致
+	"chrome_desktop": {
+		"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36",
+		"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+		"Accept-Language":           "en-US,en;q=0.9",
+		"Sec-Ch-Ua":                 `"Not(A:Brand";v="99", "Google Chrome";v="133", "Chromium";v="133"`,
+		"Sec-Ch-Ua-Mobile":          "?0",
+		"Sec-Ch-Ua-Platform":        `"Windows"`,
+		"Upgrade-Insecure-Requests": "1",
+		"Sec-Fetch-Site":            "none",
+		"Sec-Fetch-Mode":            "navigate",
+		"Sec-Fetch-User":            "?1",
+		"Sec-Fetch-Dest":            "document",
+	},
+	// iPhone上的Safari，与TLS指纹safari_16版本一致；Safari不发送Sec-CH-UA
+	"safari_mobile": {
+		"User-Agent":      "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Language": "en-US,en;q=0.9",
+		"Sec-Fetch-Site":  "none",
+		"Sec-Fetch-Mode":  "navigate",
+		"Sec-Fetch-Dest":  "document",
+	},
+	// 与curl命令行默认请求头一致
+	"curl": {
+		"User-Agent": "curl/8.5.0",
+		"Accept":     "*/*",
+	},
+	// 调用JSON接口的程序化客户端
+	"api_client": {
+		"User-Agent": "GoForPython/1.0",
+		"Accept":     "application/json",
+	},
+}
+
+// applyHeaderProfile 将请求头配置合并到请求中
+// 调用方已提供的同名请求头（不区分大小写）优先；合并结果写入新的map，
+// 调用方的headers可能被并发请求共用，不能原地修改
+func applyHeaderProfile(spec *Request) error {
+	if spec.HeaderProfile == "" {
+		return nil
+	}
+	profile, ok := headerProfiles[strings.ToLower(spec.HeaderProfile)]
+	if !ok {
+		return fmt.Errorf("请求头配置无效: 未知的header_profile %s", spec.HeaderProfile)
+	}
+	headers := make(map[string]string, len(spec.Headers)+len(profile))
+	for name, value := range spec.Headers {
+		headers[name] = value
+	}
+	for name, value := range profile {
+		if !hasHeader(spec.Headers, name) {
+			headers[name] = value
+		}
+	}
+	spec.Headers = headers
+	return nil
+}
+
+// checkUserAgent 按策略校验User-Agent
+func checkUserAgent(spec *Request) error {
+	switch strings.ToLower(spec.UserAgentPolicy) {
+	case "", userAgentRequired:
+		// 在解析headers后增加必要字段校验
+		if !hasHeader(spec.Headers, "User-Agent") {
+			return fmt.Errorf("必须提供User-Agent请求头")
+		}
+	case userAgentOptional:
+	default:
+		return fmt.Errorf("请求头配置无效: 未知的user_agent_policy %s", spec.UserAgentPolicy)
+	}
+	return nil
+}
+
+// hasHeader 不区分大小写地判断请求头是否存在
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
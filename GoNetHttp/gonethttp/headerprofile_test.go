@@ -0,0 +1,70 @@
+package gonethttp
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHeaderProfileDoesNotMutateRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Accept") + "|" + r.UserAgent()))
+	}))
+	defer srv.Close()
+	c, err := NewClient(ClientConfig{HeaderProfile: "api_client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	headers := map[string]string{"user-agent": "mine"}
+	req := &Request{
+		Method:  "GET",
+		URL:     srv.URL,
+		Headers: headers,
+		Auth:    &AuthConfig{Type: "BEARER", Token: "tok"},
+		Sign:    &SignConfig{Type: signHMAC, Secret: "key"},
+	}
+	before := *req
+	// 多个请求共用同一个headers，并发合并请求头不能写入共享的map
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.Do(context.Background(), req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.Text != "application/json|mine" {
+				t.Errorf("server saw %q, want profile Accept with the caller's User-Agent", res.Text)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !maps.Equal(headers, map[string]string{"user-agent": "mine"}) {
+		t.Fatalf("caller headers mutated: %v", headers)
+	}
+	if req.Method != before.Method || req.Auth.Type != "BEARER" || req.Sign.Components != nil || req.Sign.Header != "" {
+		t.Fatalf("caller request mutated: %+v auth=%+v sign=%+v", req, req.Auth, req.Sign)
+	}
+}
+
+func TestHeaderProfileCallerWins(t *testing.T) {
+	spec := &Request{HeaderProfile: "CURL", Headers: map[string]string{"accept": "text/plain"}}
+	if err := applyHeaderProfile(spec); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"accept": "text/plain", "User-Agent": "curl/8.5.0"}
+	if !maps.Equal(spec.Headers, want) {
+		t.Fatalf("headers = %v, want %v", spec.Headers, want)
+	}
+	if err := applyHeaderProfile(&Request{HeaderProfile: "netscape"}); ErrorCode(err) != ErrHeaderProfile {
+		t.Fatalf("unknown profile: %v, want code %d", err, ErrHeaderProfile)
+	}
+}
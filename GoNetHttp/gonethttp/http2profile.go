@@ -0,0 +1,243 @@
+// http2profile.go
+package gonethttp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// HTTP/2帧类型与标志（RFC 9113 6）
+const (
+	frameHeaders      = 0x1
+	frameSettings     = 0x4
+	frameContinuation = 0x9
+
+	flagEndStream  = 0x1
+	flagAck        = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+
+	frameHeaderLen  = 9
+	minMaxFrameSize = 16384 // 对端允许的最小帧长上限，改写后的头部块按此拆分
+	http2Preface    = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+)
+
+// pseudoHeaderNames 伪头部顺序中的缩写（与Akamai指纹格式一致）
+var pseudoHeaderNames = map[string]string{"m": ":method", "a": ":authority", "s": ":scheme", "p": ":path"}
+
+// http2Conn 按浏览器指纹改写Go内置HTTP/2实现写出的帧
+// Go的SETTINGS顺序与伪头部顺序是固定的，这里在写入TLS连接前改写：
+//  1. 连接前言后的第一个SETTINGS帧替换为配置中的SETTINGS（顺序与取值均按浏览器）
+//  2. HEADERS帧（含CONTINUATION）解码后按浏览器顺序重排伪头部再编码
+//
+// 影响流控与解码的取值（窗口、头部表大小、帧长）已由applyHTTP2写入传输层，改写前后双方状态一致；
+// 重新编码不使用动态表，因此无需跟踪对端的SETTINGS_HEADER_TABLE_SIZE
+type http2Conn struct {
+	*utlsConn
+	profile *tlsProfile
+
+	mu       sync.Mutex
+	pending  []byte // 尚未组成完整帧头（或需要整帧改写的帧）的数据
+	preface  bool   // 已转发连接前言
+	settings bool   // 已改写第一个SETTINGS帧
+	forward  int    // 当前透传帧剩余的载荷字节数
+	err      error
+
+	dec    *hpack.Decoder // 跟踪Go编码器的动态表，用于解码原始头部块
+	enc    *hpack.Encoder // 重新编码，不使用动态表
+	encBuf bytes.Buffer
+
+	block       []byte // 等待CONTINUATION的头部块
+	blockStream uint32
+	blockFlags  uint8
+	blockPrio   http2.PriorityParam
+}
+
+// newHTTP2Conn 包装协商出h2的uTLS连接
+func newHTTP2Conn(conn *utlsConn, profile *tlsProfile) *http2Conn {
+	c := &http2Conn{utlsConn: conn, profile: profile}
+	c.dec = hpack.NewDecoder(4096, nil)
+	c.enc = hpack.NewEncoder(&c.encBuf)
+	c.enc.SetMaxDynamicTableSizeLimit(0)
+	return c
+}
+
+func (c *http2Conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.pending = append(c.pending, p...)
+	out, err := c.rewrite()
+	if err == nil && len(out) > 0 {
+		_, err = c.utlsConn.Write(out)
+	}
+	if err != nil {
+		c.err = err
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rewrite 处理已缓冲的数据，返回可以写出的字节
+// 普通帧的载荷随到随转发；SETTINGS、HEADERS、CONTINUATION需要收齐整帧后改写
+func (c *http2Conn) rewrite() ([]byte, error) {
+	var out bytes.Buffer
+	buf := c.pending
+	defer func() {
+		c.pending = append(c.pending[:0], buf...)
+	}()
+	if !c.preface {
+		if len(buf) < len(http2Preface) {
+			return nil, nil
+		}
+		if string(buf[:len(http2Preface)]) != http2Preface {
+			return nil, fmt.Errorf("HTTP/2帧改写失败: 缺少连接前言")
+		}
+		out.Write(buf[:len(http2Preface)])
+		buf = buf[len(http2Preface):]
+		c.preface = true
+	}
+	for len(buf) > 0 {
+		if c.forward > 0 {
+			n := min(c.forward, len(buf))
+			out.Write(buf[:n])
+			buf = buf[n:]
+			c.forward -= n
+			continue
+		}
+		if len(buf) < frameHeaderLen {
+			break
+		}
+		length := int(buf[0])<<16 | int(buf[1])<<8 | int(buf[2])
+		typ, flags := buf[3], buf[4]
+		stream := binary.BigEndian.Uint32(buf[5:9]) & (1<<31 - 1)
+		rewritten := typ == frameHeaders || typ == frameContinuation ||
+			(typ == frameSettings && flags&flagAck == 0 && !c.settings)
+		if !rewritten {
+			out.Write(buf[:frameHeaderLen])
+			buf = buf[frameHeaderLen:]
+			c.forward = length
+			continue
+		}
+		if len(buf) < frameHeaderLen+length {
+			break
+		}
+		payload := buf[frameHeaderLen : frameHeaderLen+length]
+		buf = buf[frameHeaderLen+length:]
+		if err := c.rewriteFrame(&out, typ, flags, stream, payload); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// rewriteFrame 改写一个完整的SETTINGS/HEADERS/CONTINUATION帧
+func (c *http2Conn) rewriteFrame(out *bytes.Buffer, typ, flags uint8, stream uint32, payload []byte) error {
+	fr := http2.NewFramer(out, nil)
+	switch typ {
+	case frameSettings:
+		c.settings = true
+		return fr.WriteSettings(c.profile.http2Settings...)
+	case frameHeaders:
+		if flags&flagPadded != 0 {
+			if len(payload) < 1 || int(payload[0]) >= len(payload) {
+				return fmt.Errorf("HTTP/2帧改写失败: HEADERS填充长度错误")
+			}
+			payload = payload[1 : len(payload)-int(payload[0])]
+		}
+		c.blockPrio = http2.PriorityParam{}
+		if flags&flagPriority != 0 {
+			if len(payload) < 5 {
+				return fmt.Errorf("HTTP/2帧改写失败: HEADERS优先级字段错误")
+			}
+			dep := binary.BigEndian.Uint32(payload[:4])
+			c.blockPrio = http2.PriorityParam{
+				StreamDep: dep & (1<<31 - 1),
+				Exclusive: dep>>31 == 1,
+				Weight:    payload[4],
+			}
+			payload = payload[5:]
+		}
+		c.block = append(c.block[:0], payload...)
+		c.blockStream, c.blockFlags = stream, flags
+	case frameContinuation:
+		if stream != c.blockStream {
+			return fmt.Errorf("HTTP/2帧改写失败: CONTINUATION不属于当前头部块")
+		}
+		c.block = append(c.block, payload...)
+		c.blockFlags |= flags & flagEndHeaders
+	}
+	if c.blockFlags&flagEndHeaders == 0 {
+		return nil
+	}
+	block, err := c.reencode(c.block)
+	if err != nil {
+		return err
+	}
+	first := block[:min(len(block), minMaxFrameSize)]
+	block = block[len(first):]
+	if err := fr.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      c.blockStream,
+		BlockFragment: first,
+		EndStream:     c.blockFlags&flagEndStream != 0,
+		EndHeaders:    len(block) == 0,
+		Priority:      c.blockPrio,
+	}); err != nil {
+		return err
+	}
+	for len(block) > 0 {
+		frag := block[:min(len(block), minMaxFrameSize)]
+		block = block[len(frag):]
+		if err := fr.WriteContinuation(c.blockStream, len(block) == 0, frag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencode 解码Go编码的头部块，按配置的顺序重排伪头部后重新编码
+func (c *http2Conn) reencode(block []byte) ([]byte, error) {
+	fields, err := c.dec.DecodeFull(block)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP/2帧改写失败: %v", err)
+	}
+	c.encBuf.Reset()
+	for _, f := range orderPseudoHeaders(fields, c.profile.pseudoHeaderOrder) {
+		if err := c.enc.WriteField(f); err != nil {
+			return nil, fmt.Errorf("HTTP/2帧改写失败: %v", err)
+		}
+	}
+	return bytes.Clone(c.encBuf.Bytes()), nil
+}
+
+// orderPseudoHeaders 按顺序（如"m,a,s,p"）排列伪头部，未列出的伪头部（如:protocol）随后，普通头部保持原顺序
+func orderPseudoHeaders(fields []hpack.HeaderField, order string) []hpack.HeaderField {
+	out := make([]hpack.HeaderField, 0, len(fields))
+	used := make([]bool, len(fields))
+	for _, key := range strings.Split(order, ",") {
+		for i, f := range fields {
+			if !used[i] && f.Name == pseudoHeaderNames[key] {
+				out = append(out, f)
+				used[i] = true
+			}
+		}
+	}
+	for _, pseudo := range []bool{true, false} {
+		for i, f := range fields {
+			if !used[i] && f.IsPseudo() == pseudo {
+				out = append(out, f)
+				used[i] = true
+			}
+		}
+	}
+	return out
+}
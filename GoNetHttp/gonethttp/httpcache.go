@@ -0,0 +1,577 @@
+// httpcache.go
+package gonethttp
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 缓存结果，写入返回数据的cache字段
+const (
+	cacheHit         = "hit"         // 直接使用新鲜的缓存
+	cacheRevalidated = "revalidated" // 条件请求返回304，使用缓存的响应体
+	cacheNetwork     = "network"     // 来自网络
+)
+
+// CacheConfig 客户端HTTP缓存配置（RFC 9111私有缓存）
+// 只缓存GET请求，POST成功后使同一URL的缓存失效
+//
+// 示例：
+//
+//	{"type": "memory", "max_entries": 1000}
+//	{"type": "disk", "dir": "./http-cache"}
+type CacheConfig struct {
+	Type          string `json:"type"`            // memory（默认，LRU）/disk
+	Dir           string `json:"dir"`             // 磁盘缓存目录（disk必填）
+	MaxEntries    int    `json:"max_entries"`     // 缓存条目上限（内存与磁盘），默认1000
+	MaxEntryBytes int64  `json:"max_entry_bytes"` // 可缓存的响应体上限，默认5MB
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	StatusCode   int               `json:"status_code"`
+	Proto        string            `json:"proto"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	Vary         map[string]string `json:"vary"`          // Vary列出的请求头及存储时的取值
+	RequestTime  time.Time         `json:"request_time"`  // 发出请求的时间
+	ResponseTime time.Time         `json:"response_time"` // 收到响应的时间
+}
+
+// cacheStore 缓存存储后端
+type cacheStore interface {
+	get(key string) (*cacheEntry, bool)
+	set(key string, entry *cacheEntry)
+	remove(key string)
+}
+
+// newCacheStore 根据配置创建存储，未配置时返回nil
+func newCacheStore(cfg *CacheConfig) (cacheStore, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	limit := cfg.MaxEntries
+	if limit <= 0 {
+		limit = 1000
+	}
+	switch cfg.Type {
+	case "", "memory":
+		return &memoryCache{max: limit, items: map[string]*list.Element{}, order: list.New()}, nil
+	case "disk":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("缓存配置错误: disk缓存需要dir")
+		}
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("缓存配置错误: 创建缓存目录失败: %v", err)
+		}
+		d := &diskCache{dir: cfg.Dir, max: limit}
+		d.count = len(d.files())
+		return d, nil
+	default:
+		return nil, fmt.Errorf("缓存配置错误: 未知的缓存类型 %s", cfg.Type)
+	}
+}
+
+// memoryCache 按最近使用淘汰的内存缓存
+type memoryCache struct {
+	mu    sync.Mutex
+	max   int
+	items map[string]*list.Element
+	order *list.List // 头部为最近使用
+}
+
+type memoryItem struct {
+	key   string
+	entry *cacheEntry
+}
+
+func (m *memoryCache) get(key string) (*cacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+func (m *memoryCache) set(key string, entry *cacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return
+	}
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	for m.order.Len() > m.max {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+func (m *memoryCache) remove(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.order.Remove(el)
+		delete(m.items, key)
+	}
+}
+
+// diskCache 每个URL一个JSON文件的磁盘缓存，进程重启后仍可使用
+// 文件修改时间记录最近使用时间，文件数超过上限时删除最久未使用的
+type diskCache struct {
+	dir   string
+	max   int
+	mu    sync.Mutex
+	count int // 目录中的缓存文件数，淘汰时按目录内容校正
+}
+
+func (d *diskCache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (d *diskCache) get(key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(d.file(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(d.file(key), now, now)
+	return &entry, true
+}
+
+func (d *diskCache) set(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 先写临时文件再改名，避免并发读到半个文件
+	tmp := d.file(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_, errStat := os.Stat(d.file(key))
+	if err := os.Rename(tmp, d.file(key)); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if os.IsNotExist(errStat) {
+		d.count++
+		if d.count > d.max {
+			d.evict()
+		}
+	}
+}
+
+func (d *diskCache) remove(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if os.Remove(d.file(key)) == nil {
+		d.count--
+	}
+}
+
+// diskFile 缓存目录中的缓存文件
+type diskFile struct {
+	path    string
+	modTime time.Time
+}
+
+// files 列出缓存目录中的缓存文件
+func (d *diskCache) files() []diskFile {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil
+	}
+	var files []diskFile
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, diskFile{path: filepath.Join(d.dir, e.Name()), modTime: info.ModTime()})
+	}
+	return files
+}
+
+// evict 删除最久未使用的文件直到不超过上限，调用方需持有mu
+func (d *diskCache) evict() {
+	files := d.files()
+	slices.SortFunc(files, func(a, b diskFile) int { return a.modTime.Compare(b.modTime) })
+	for len(files) > d.max {
+		os.Remove(files[0].path)
+		files = files[1:]
+	}
+	d.count = len(files)
+}
+
+// cacheStatusKey 在请求上下文中记录缓存结果（重定向时为最后一跳）
+type cacheStatusKey struct{}
+
+// cacheStatus 缓存结果记录
+type cacheStatus struct {
+	mu     sync.Mutex
+	status string
+}
+
+func (s *cacheStatus) set(status string) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func (s *cacheStatus) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status == "" {
+		return cacheNetwork
+	}
+	return s.status
+}
+
+// cachingTransport 按RFC 9111使用缓存应答请求
+// 缓存位于认证与签名之外，看不到内层添加的凭据，由authenticated标记请求是否携带凭据
+type cachingTransport struct {
+	base          http.RoundTripper
+	store         cacheStore
+	maxBytes      int64
+	authenticated bool
+}
+
+// heuristicStatus 没有显式有效期时可按Last-Modified启发式缓存的状态码
+// 不含206：缓存键不区分Range，部分响应不能当作完整响应使用
+var heuristicStatus = map[int]bool{200: true, 203: true, 204: true, 300: true, 301: true, 308: true, 404: true, 405: true, 410: true, 414: true, 501: true}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	if req.Method == http.MethodGet && req.Header.Get("Range") != "" {
+		// 范围请求既不使用也不写入缓存
+		return t.base.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet {
+		res, err := t.base.RoundTrip(req)
+		// 不安全的方法成功后使缓存失效（RFC 9111 4.4）
+		if err == nil && res.StatusCode < 400 {
+			t.store.remove(key)
+		}
+		return res, err
+	}
+	record := func(status string) {
+		if s, ok := req.Context().Value(cacheStatusKey{}).(*cacheStatus); ok {
+			s.set(status)
+		}
+	}
+	reqCC := parseCacheControl(req.Header.Values("Cache-Control"))
+	_, noStore := reqCC["no-store"]
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	entry, ok := t.store.get(key)
+	if ok && (!entry.matchesVary(req) || noStore || conditional) {
+		entry, ok = nil, false
+	}
+	if ok && entry.fresh(reqCC, time.Now()) {
+		record(cacheHit)
+		return entry.response(req), nil
+	}
+	if _, onlyIfCached := reqCC["only-if-cached"]; onlyIfCached {
+		// 没有可用的缓存时返回504，不访问网络（RFC 9111 5.2.1.7）
+		return gatewayTimeout(req), nil
+	}
+	outgoing := req
+	if ok {
+		// 用验证器发起条件请求
+		outgoing = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			outgoing.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+	requestTime := time.Now()
+	res, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+	if ok && res.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		// 用304中的头部更新缓存（RFC 9111 4.3.4）
+		updated := *entry
+		updated.Header = entry.Header.Clone()
+		for name, values := range res.Header {
+			if !strings.EqualFold(name, "Content-Length") {
+				updated.Header[name] = values
+			}
+		}
+		updated.RequestTime, updated.ResponseTime = requestTime, responseTime
+		t.store.set(key, &updated)
+		record(cacheRevalidated)
+		return updated.response(req), nil
+	}
+	record(cacheNetwork)
+	// Cookie可能来自请求的cookies字段、Cookie请求头或客户端的Cookie罐，在此处都已写入请求头
+	if noStore || !storable(res, t.authenticated, req.Header.Get("Cookie") != "") {
+		return res, nil
+	}
+	// 读取响应体以便存储，超过上限的响应不缓存
+	body, err := io.ReadAll(io.LimitReader(res.Body, t.maxBytes+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.maxBytes {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	t.store.set(key, &cacheEntry{
+		StatusCode:   res.StatusCode,
+		Proto:        res.Proto,
+		Header:       res.Header.Clone(),
+		Body:         body,
+		Vary:         varyValues(res.Header, req),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	})
+	return res, nil
+}
+
+// storable 判断响应是否可存入私有缓存（RFC 9111 3）
+// 参数 authenticated: 请求携带凭据时只存储明确允许共享（public或s-maxage）的响应（RFC 9111 3.5），
+// 避免同一客户端上不带凭据的请求拿到需要认证的内容
+// 参数 withCookie: 请求携带Cookie时响应可能与会话相关，只存储声明了public的响应
+func storable(res *http.Response, authenticated, withCookie bool) bool {
+	cc := parseCacheControl(res.Header.Values("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if res.StatusCode == http.StatusPartialContent {
+		return false
+	}
+	if authenticated {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		if !public && !sMaxAge {
+			return false
+		}
+	}
+	if _, public := cc["public"]; withCookie && !public {
+		return false
+	}
+	for _, vary := range res.Header.Values("Vary") {
+		if strings.TrimSpace(vary) == "*" {
+			return false
+		}
+	}
+	if _, ok := cc["max-age"]; ok {
+		return true
+	}
+	if _, ok := cc["public"]; ok {
+		return true
+	}
+	if res.Header.Get("Expires") != "" {
+		return true
+	}
+	// 有验证器时即使立即过期也值得存储，用于条件请求
+	if res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != "" {
+		return heuristicStatus[res.StatusCode] || res.StatusCode == http.StatusOK
+	}
+	return false
+}
+
+// fresh 判断缓存能否直接用于当前请求（RFC 9111 4.2、5.2.1）
+// 请求的max-age限制可接受的年龄，min-fresh要求剩余有效期，
+// max-stale允许使用过期的缓存（响应带must-revalidate时不允许）
+func (e *cacheEntry) fresh(reqCC map[string]string, now time.Time) bool {
+	cc := parseCacheControl(e.Header.Values("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	if _, ok := reqCC["no-cache"]; ok {
+		return false
+	}
+	if e.Header.Get("Pragma") == "no-cache" && e.Header.Get("Cache-Control") == "" {
+		return false
+	}
+	lifetime := e.freshnessLifetime(cc)
+	age := e.currentAge(now)
+	if maxAge, ok := directiveSeconds(reqCC, "max-age"); ok && age > maxAge {
+		return false
+	}
+	if minFresh, ok := directiveSeconds(reqCC, "min-fresh"); ok {
+		age += minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+	maxStale, ok := reqCC["max-stale"]
+	if !ok {
+		return false
+	}
+	if _, mustRevalidate := cc["must-revalidate"]; mustRevalidate {
+		return false
+	}
+	if maxStale == "" {
+		return true
+	}
+	seconds, err := strconv.Atoi(maxStale)
+	return err == nil && age-lifetime <= time.Duration(seconds)*time.Second
+}
+
+// directiveSeconds 读取以秒为单位的Cache-Control指令参数
+func directiveSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// gatewayTimeout only-if-cached未命中时返回的504响应
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// freshnessLifetime 计算有效期：max-age → Expires-Date → Last-Modified启发式（10%）
+func (e *cacheEntry) freshnessLifetime(cc map[string]string) time.Duration {
+	if maxAge, ok := cc["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date := e.date()
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristicStatus[e.StatusCode] {
+		if _, mustRevalidate := cc["must-revalidate"]; !mustRevalidate {
+			return date.Sub(lastModified) / 10
+		}
+	}
+	return 0
+}
+
+// date 响应的Date头，缺失时使用收到响应的时间
+func (e *cacheEntry) date() time.Time {
+	if t, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return t
+	}
+	return e.ResponseTime
+}
+
+// currentAge 计算缓存的当前年龄（RFC 9111 4.2.3）
+func (e *cacheEntry) currentAge(now time.Time) time.Duration {
+	apparentAge := max(0, e.ResponseTime.Sub(e.date()))
+	ageValue, _ := strconv.Atoi(e.Header.Get("Age"))
+	correctedAge := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+	return max(apparentAge, correctedAge) + now.Sub(e.ResponseTime)
+}
+
+// matchesVary 判断请求在Vary列出的请求头上与存储时一致
+func (e *cacheEntry) matchesVary(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if strings.Join(req.Header.Values(name), ", ") != value {
+			return false
+		}
+	}
+	return true
+}
+
+// response 由缓存构造响应，Age头为当前年龄
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.currentAge(time.Now()).Seconds())))
+	major, minor, _ := http.ParseHTTPVersion(e.Proto)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         e.Proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// varyValues 记录Vary列出的请求头取值
+func varyValues(header http.Header, req *http.Request) map[string]string {
+	values := map[string]string{}
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name != "" {
+				values[name] = strings.Join(req.Header.Values(name), ", ")
+			}
+		}
+	}
+	return values
+}
+
+// parseCacheControl 解析Cache-Control指令，键为小写指令名
+func parseCacheControl(values []string) map[string]string {
+	cc := map[string]string{}
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg, _ := strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return cc
+}
@@ -0,0 +1,250 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cacheServer 按请求返回可缓存的响应并统计访问次数
+type cacheServer struct {
+	hits         atomic.Int32
+	cacheControl string
+}
+
+func (s *cacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := s.hits.Add(1)
+	w.Header().Set("Cache-Control", s.cacheControl)
+	if r.Header.Get("Range") != "" {
+		w.Header().Set("Content-Range", "bytes 0-1/10")
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, "01")
+		return
+	}
+	fmt.Fprintf(w, "body%d", n)
+}
+
+func newCacheClient(t *testing.T) *Client {
+	t.Helper()
+	c, err := NewClient(ClientConfig{Cache: &CacheConfig{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func cacheGet(t *testing.T, c *Client, url string, headers map[string]string, auth *AuthConfig) *Response {
+	t.Helper()
+	h := map[string]string{"User-Agent": "test"}
+	for k, v := range headers {
+		h[k] = v
+	}
+	res, err := c.Do(context.Background(), &Request{Method: "GET", URL: url, Headers: h, Auth: auth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestCacheIgnoresRangeRequests(t *testing.T) {
+	handler := &cacheServer{cacheControl: "max-age=60"}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newCacheClient(t)
+
+	if res := cacheGet(t, c, srv.URL, map[string]string{"Range": "bytes=0-1"}, nil); res.StatusCode != http.StatusPartialContent {
+		t.Fatalf("range request: got %d, want 206", res.StatusCode)
+	}
+	res := cacheGet(t, c, srv.URL, nil, nil)
+	if res.StatusCode != http.StatusOK || res.Text != "body2" || res.Cache != cacheNetwork {
+		t.Fatalf("full request: got %d %q (%s), want 200 body2 from network", res.StatusCode, res.Text, res.Cache)
+	}
+	// 已缓存的完整响应也不能用于范围请求
+	if res := cacheGet(t, c, srv.URL, map[string]string{"Range": "bytes=0-1"}, nil); res.StatusCode != http.StatusPartialContent {
+		t.Fatalf("range request after full: got %d %q, want 206", res.StatusCode, res.Text)
+	}
+}
+
+func TestCacheAuthenticatedResponses(t *testing.T) {
+	auth := &AuthConfig{Type: "bearer", Token: "secret"}
+	for _, tc := range []struct {
+		cacheControl string
+		stored       bool
+	}{
+		{"max-age=60", false},
+		{"private, max-age=60", false},
+		{"public, max-age=60", true},
+		{"s-maxage=60, max-age=60", true},
+	} {
+		t.Run(tc.cacheControl, func(t *testing.T) {
+			handler := &cacheServer{cacheControl: tc.cacheControl}
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+			c := newCacheClient(t)
+
+			cacheGet(t, c, srv.URL, nil, auth)
+			res := cacheGet(t, c, srv.URL, nil, nil)
+			if got := res.Cache == cacheHit; got != tc.stored {
+				t.Fatalf("unauthenticated request after authenticated: cache = %s, stored = %v, want %v", res.Cache, got, tc.stored)
+			}
+		})
+	}
+}
+
+func TestCacheCallerAuthorizationHeader(t *testing.T) {
+	handler := &cacheServer{cacheControl: "max-age=60"}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newCacheClient(t)
+
+	cacheGet(t, c, srv.URL, map[string]string{"Authorization": "Bearer secret"}, nil)
+	if res := cacheGet(t, c, srv.URL, nil, nil); res.Cache != cacheNetwork || res.Text != "body2" {
+		t.Fatalf("got %q (%s), want body2 from network", res.Text, res.Cache)
+	}
+}
+
+func TestCacheOnlyIfCached(t *testing.T) {
+	handler := &cacheServer{cacheControl: "max-age=60"}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newCacheClient(t)
+
+	onlyIfCached := map[string]string{"Cache-Control": "only-if-cached"}
+	if res := cacheGet(t, c, srv.URL, onlyIfCached, nil); res.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("empty cache: got %d, want 504", res.StatusCode)
+	}
+	if n := handler.hits.Load(); n != 0 {
+		t.Fatalf("only-if-cached reached the server %d times", n)
+	}
+	cacheGet(t, c, srv.URL, nil, nil)
+	if res := cacheGet(t, c, srv.URL, onlyIfCached, nil); res.StatusCode != http.StatusOK || res.Cache != cacheHit {
+		t.Fatalf("cached: got %d (%s), want 200 hit", res.StatusCode, res.Cache)
+	}
+}
+
+func TestCacheRequestFreshnessDirectives(t *testing.T) {
+	now := time.Now()
+	entry := func(cacheControl string) *cacheEntry {
+		// 响应已存放90秒
+		return &cacheEntry{
+			StatusCode:   http.StatusOK,
+			Header:       http.Header{"Cache-Control": {cacheControl}},
+			RequestTime:  now.Add(-90 * time.Second),
+			ResponseTime: now.Add(-90 * time.Second),
+		}
+	}
+	for _, tc := range []struct {
+		response, request string
+		fresh             bool
+	}{
+		{"max-age=60", "", false},
+		{"max-age=60", "max-stale", true},
+		{"max-age=60", "max-stale=60", true},
+		{"max-age=60", "max-stale=10", false},
+		{"max-age=60, must-revalidate", "max-stale", false},
+		{"max-age=120", "", true},
+		{"max-age=120", "min-fresh=20", true},
+		{"max-age=120", "min-fresh=40", false},
+		{"max-age=120", "max-age=60", false},
+	} {
+		reqCC := parseCacheControl([]string{tc.request})
+		if got := entry(tc.response).fresh(reqCC, now); got != tc.fresh {
+			t.Errorf("response %q, request %q: fresh = %v, want %v", tc.response, tc.request, got, tc.fresh)
+		}
+	}
+}
+
+func TestCacheConfigNotModified(t *testing.T) {
+	cfg := &CacheConfig{}
+	c, err := NewClient(ClientConfig{Cache: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if cfg.MaxEntries != 0 {
+		t.Fatalf("caller config modified: %+v", *cfg)
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	cfg := &CacheConfig{Type: "disk", Dir: t.TempDir(), MaxEntries: 2}
+	store, err := newCacheStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := store.(*diskCache)
+	past := time.Now().Add(-time.Hour)
+	for i, key := range []string{"a", "b"} {
+		d.set(key, &cacheEntry{StatusCode: http.StatusOK})
+		used := past.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(d.file(key), used, used)
+	}
+	// 读取a后b成为最久未使用
+	if _, ok := d.get("a"); !ok {
+		t.Fatal("a not stored")
+	}
+	d.set("c", &cacheEntry{StatusCode: http.StatusOK})
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := d.get(key); ok != want {
+			t.Errorf("%s present = %v, want %v", key, ok, want)
+		}
+	}
+
+	// 重新打开时按已有文件计数
+	reopened, err := newCacheStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.set("d", &cacheEntry{StatusCode: http.StatusOK})
+	if n := len(reopened.(*diskCache).files()); n != 2 {
+		t.Fatalf("%d files after reopening and inserting, want 2", n)
+	}
+}
+
+func TestCacheCookieRequests(t *testing.T) {
+	for _, tc := range []struct {
+		cacheControl string
+		stored       bool
+	}{
+		{"max-age=60", false},
+		{"public, max-age=60", true},
+	} {
+		for _, source := range []string{"header", "cookies", "jar"} {
+			t.Run(tc.cacheControl+"/"+source, func(t *testing.T) {
+				handler := &cacheServer{cacheControl: tc.cacheControl}
+				srv := httptest.NewServer(handler)
+				defer srv.Close()
+				c, err := NewClient(ClientConfig{Cache: &CacheConfig{}, CookieJar: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer c.Close()
+				req := &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}}
+				switch source {
+				case "header":
+					req.Headers["Cookie"] = "session=1"
+				case "cookies":
+					req.Cookies = map[string]string{"session": "1"}
+				case "jar":
+					if _, err := c.ImportCookies(`[{"name": "session", "value": "1", "domain": "127.0.0.1", "path": "/"}]`, "json"); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for range 2 {
+					if _, err := c.Do(context.Background(), req); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if stored := handler.hits.Load() == 1; stored != tc.stored {
+					t.Fatalf("server hits = %d, stored = %v, want %v", handler.hits.Load(), stored, tc.stored)
+				}
+			})
+		}
+	}
+}
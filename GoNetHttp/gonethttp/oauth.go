@@ -0,0 +1,209 @@
+// oauth.go
+package gonethttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2授权方式
+const (
+	grantClientCredentials = "client_credentials"
+	grantRefreshToken      = "refresh_token"
+)
+
+// OAuthConfig 客户端句柄的OAuth2令牌配置
+// 令牌在首次请求时获取并缓存，过期前refresh_before秒或收到401时刷新
+//
+// 示例：
+//
+//	{"token_url": "https://auth.example.com/oauth/token", "grant_type": "client_credentials",
+//	 "client_id": "id", "client_secret": "secret", "scopes": ["read", "write"]}
+//	{"token_url": "https://auth.example.com/oauth/token", "grant_type": "refresh_token",
+//	 "client_id": "id", "refresh_token": "xxx"}
+type OAuthConfig struct {
+	TokenURL      string            `json:"token_url"`      // 令牌端点
+	GrantType     string            `json:"grant_type"`     // client_credentials/refresh_token
+	ClientID      string            `json:"client_id"`      // 客户端ID
+	ClientSecret  string            `json:"client_secret"`  // 客户端密钥
+	Scopes        []string          `json:"scopes"`         // 申请的权限范围
+	RefreshToken  string            `json:"refresh_token"`  // 初始刷新令牌（refresh_token方式必填）
+	AuthStyle     string            `json:"auth_style"`     // 客户端凭据发送方式：header（Basic认证，默认）/body（表单字段）
+	RefreshBefore int               `json:"refresh_before"` // 提前刷新的秒数，默认60
+	Params        map[string]string `json:"params"`         // 额外的表单参数，如audience
+}
+
+// tokenSource 缓存访问令牌，并发刷新时只有一个请求访问令牌端点
+type tokenSource struct {
+	cfg          OAuthConfig
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiry       time.Time // 零值表示服务器未声明有效期
+}
+
+// newTokenSource 根据配置创建令牌源，未配置时返回nil
+func newTokenSource(cfg *OAuthConfig) (*tokenSource, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if u, err := url.Parse(cfg.TokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("OAuth2配置错误: 无效的token_url %q", cfg.TokenURL)
+	}
+	switch cfg.GrantType {
+	case grantClientCredentials:
+		if cfg.ClientID == "" {
+			return nil, fmt.Errorf("OAuth2配置错误: client_credentials需要client_id")
+		}
+	case grantRefreshToken:
+		if cfg.RefreshToken == "" {
+			return nil, fmt.Errorf("OAuth2配置错误: refresh_token方式需要refresh_token")
+		}
+	default:
+		return nil, fmt.Errorf("OAuth2配置错误: 不支持的grant_type %s", cfg.GrantType)
+	}
+	switch cfg.AuthStyle {
+	case "", "header", "body":
+	default:
+		return nil, fmt.Errorf("OAuth2配置错误: 未知的auth_style %s", cfg.AuthStyle)
+	}
+	s := &tokenSource{cfg: *cfg, refreshToken: cfg.RefreshToken}
+	if s.cfg.RefreshBefore <= 0 {
+		s.cfg.RefreshBefore = 60
+	}
+	return s, nil
+}
+
+// token 返回有效的访问令牌，必要时刷新
+// 参数 stale: 收到401的请求所用的令牌，与缓存相同时强制刷新；为空表示正常获取
+func (s *tokenSource) token(ctx context.Context, rt http.RoundTripper, stale string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	valid := s.accessToken != "" && (s.expiry.IsZero() || time.Now().Before(s.expiry))
+	if valid && (stale == "" || stale != s.accessToken) {
+		return s.accessToken, nil
+	}
+	if err := s.refresh(ctx, rt); err != nil {
+		return "", err
+	}
+	return s.accessToken, nil
+}
+
+// tokenResponse 令牌端点的返回（RFC 6749 5.1）
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+}
+
+// refresh 访问令牌端点，调用方需持有锁
+func (s *tokenSource) refresh(ctx context.Context, rt http.RoundTripper) error {
+	form := url.Values{"grant_type": {s.cfg.GrantType}}
+	if s.cfg.GrantType == grantRefreshToken {
+		form.Set("refresh_token", s.refreshToken)
+	}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	for key, value := range s.cfg.Params {
+		form.Set(key, value)
+	}
+	if s.cfg.AuthStyle == "body" {
+		form.Set("client_id", s.cfg.ClientID)
+		if s.cfg.ClientSecret != "" {
+			form.Set("client_secret", s.cfg.ClientSecret)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("OAuth2令牌获取失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.AuthStyle != "body" && s.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+	res, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return fmt.Errorf("OAuth2令牌获取失败: %v", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return fmt.Errorf("OAuth2令牌获取失败: %v", err)
+	}
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return fmt.Errorf("OAuth2令牌获取失败: %s 响应无法解析", res.Status)
+	}
+	if res.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return fmt.Errorf("OAuth2令牌获取失败: %s %s %s", res.Status, tr.Error, tr.ErrorDesc)
+	}
+	s.accessToken = tr.AccessToken
+	s.expiry = time.Time{}
+	if tr.ExpiresIn > 0 {
+		// 有效期短于提前量时在有效期过半时刷新
+		lead := min(int64(s.cfg.RefreshBefore), tr.ExpiresIn/2)
+		s.expiry = time.Now().Add(time.Duration(tr.ExpiresIn-lead) * time.Second)
+	}
+	if tr.RefreshToken != "" {
+		s.refreshToken = tr.RefreshToken
+	}
+	return nil
+}
+
+// oauthTransport 为发往原始源的请求注入Bearer令牌，收到401时刷新令牌重发一次
+// 令牌端点经tokenBase访问：只包含拨号、TLS与限流层，不经过资源请求的签名与进度统计
+type oauthTransport struct {
+	base      http.RoundTripper
+	tokenBase http.RoundTripper
+	tokens    *tokenSource
+	origin    string
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if originOf(req.URL) != t.origin {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.tokens.token(req.Context(), t.tokenBase, "")
+	if err != nil {
+		return nil, err
+	}
+	first := req.Clone(req.Context())
+	first.Header.Set("Authorization", "Bearer "+token)
+	res, err := t.base.RoundTrip(first)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		// 请求体无法重放时原样返回401
+		if req.GetBody == nil {
+			return res, nil
+		}
+		body, errBody := req.GetBody()
+		if errBody != nil {
+			return res, nil
+		}
+		retry.Body = body
+	}
+	// 先关闭401响应归还连接与限流名额，否则并发上限为1时刷新令牌会一直排队
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	res.Body.Close()
+	token, err = t.tokens.token(req.Context(), t.tokenBase, token)
+	if err != nil {
+		return nil, err
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(retry)
+}
@@ -0,0 +1,130 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// oauthServer 令牌端点与受保护资源，第n次发放的令牌为tn，只接受最新令牌
+type oauthServer struct {
+	mu         sync.Mutex
+	issued     int
+	tokenFails bool
+	tokenSigs  []string // 令牌请求携带的X-Signature
+}
+
+func (s *oauthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/token":
+		s.tokenSigs = append(s.tokenSigs, r.Header.Get("X-Signature"))
+		if s.tokenFails && s.issued > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_client"}`)
+			return
+		}
+		s.issued++
+		fmt.Fprintf(w, `{"access_token": "t%d", "expires_in": 3600}`, s.issued)
+	default:
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", s.issued) || s.issued < 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "stale token")
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}
+}
+
+func newOAuthClient(t *testing.T, srv *httptest.Server, cfg ClientConfig) *Client {
+	t.Helper()
+	cfg.OAuth = &OAuthConfig{TokenURL: srv.URL + "/token", GrantType: grantClientCredentials, ClientID: "id", ClientSecret: "secret"}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestOAuthRefreshReleasesConcurrencySlot(t *testing.T) {
+	handler := &oauthServer{}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newOAuthClient(t, srv, ClientConfig{Limits: &LimitConfig{Global: &LimitRule{MaxConcurrent: 1}}})
+
+	start := time.Now()
+	res, err := c.Do(context.Background(), &Request{
+		Method:    "GET",
+		URL:       srv.URL + "/resource",
+		Headers:   map[string]string{"User-Agent": "test"},
+		TimeoutMs: 3000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Text != "ok" {
+		t.Fatalf("got %d %q, want 200 ok", res.StatusCode, res.Text)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("refresh waited %v for the concurrency slot", elapsed)
+	}
+}
+
+func TestOAuthRefreshErrorCode(t *testing.T) {
+	handler := &oauthServer{tokenFails: true}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newOAuthClient(t, srv, ClientConfig{})
+
+	_, err := c.Do(context.Background(), &Request{
+		Method:  "GET",
+		URL:     srv.URL + "/resource",
+		Headers: map[string]string{"User-Agent": "test"},
+	})
+	if code := ErrorCode(err); code != ErrOAuthToken {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrOAuthToken)
+	}
+}
+
+func TestOAuthTokenRequestNotSigned(t *testing.T) {
+	handler := &oauthServer{}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newOAuthClient(t, srv, ClientConfig{
+		Sign: &SignConfig{Type: signHMAC, Secret: "key", Components: []string{"method", "path"}},
+	})
+
+	if _, err := c.Do(context.Background(), &Request{
+		Method:  "GET",
+		URL:     srv.URL + "/resource",
+		Headers: map[string]string{"User-Agent": "test"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.tokenSigs) == 0 {
+		t.Fatal("token endpoint not called")
+	}
+	for _, sig := range handler.tokenSigs {
+		if sig != "" {
+			t.Fatalf("token request carried resource signature %q", sig)
+		}
+	}
+}
+
+func TestOAuthConfigNotModified(t *testing.T) {
+	cfg := &OAuthConfig{TokenURL: "https://auth.example.com/token", GrantType: grantClientCredentials, ClientID: "id"}
+	c, err := NewClient(ClientConfig{OAuth: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if cfg.RefreshBefore != 0 {
+		t.Fatalf("caller config modified: %+v", *cfg)
+	}
+}
@@ -0,0 +1,169 @@
+// progress.go
+package gonethttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// progressInterval 进度回调的最小间隔，传输结束时总会再回调一次
+const progressInterval = 100 * time.Millisecond
+
+// progressKey 请求上下文中进度跟踪器的键
+type progressKey struct{}
+
+// transferProgress 单个方向（上传或下载）的传输进度
+type transferProgress struct {
+	bytes   int64     // 已传输字节数
+	total   int64     // 总字节数，未知时为-1
+	started time.Time // 开始传输的时间，零值表示尚未开始
+	last    time.Time // 最近一次传输数据的时间
+}
+
+// ProgressTracker 一次请求的上传与下载进度
+// 重定向或认证重发时上传进度从零重新计数
+type ProgressTracker struct {
+	mu       sync.Mutex
+	upload   transferProgress
+	download transferProgress
+	notified time.Time // 上次通知的时间
+	finished bool      // 已发出最终通知，之后不再通知
+
+	notifyMu sync.Mutex                   // 保证通知串行执行
+	notify   func(map[string]interface{}) // 可选的进度通知
+}
+
+// NewProgressTracker 创建进度跟踪器，通过WithProgress随请求上下文传入
+// 参数 notify: 可选的进度通知，参数同Snapshot；调用间隔不小于100毫秒且串行执行，Finish时总会再调用一次
+func NewProgressTracker(notify func(map[string]interface{})) *ProgressTracker {
+	return &ProgressTracker{
+		upload:   transferProgress{total: -1},
+		download: transferProgress{total: -1},
+		notify:   notify,
+	}
+}
+
+// WithProgress 返回携带进度跟踪器的上下文，Do/Open使用该上下文时统计上传与下载进度
+func WithProgress(ctx context.Context, p *ProgressTracker) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// start 开始一个方向的传输，重置已传输字节数
+func (p *ProgressTracker) start(t *transferProgress, total int64) {
+	p.mu.Lock()
+	*t = transferProgress{total: total, started: time.Now()}
+	p.mu.Unlock()
+	p.report()
+}
+
+// add 累加已传输字节数
+func (p *ProgressTracker) add(t *transferProgress, n int) {
+	p.mu.Lock()
+	t.bytes += int64(n)
+	t.last = time.Now()
+	p.mu.Unlock()
+	p.report()
+}
+
+// report 按间隔节流调用通知
+func (p *ProgressTracker) report() {
+	if p.notify == nil {
+		return
+	}
+	p.mu.Lock()
+	now := time.Now()
+	if p.finished || now.Sub(p.notified) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.notified = now
+	p.mu.Unlock()
+	p.notifyMu.Lock()
+	defer p.notifyMu.Unlock()
+	p.mu.Lock()
+	finished := p.finished
+	p.mu.Unlock()
+	if !finished {
+		p.notify(p.Snapshot())
+	}
+}
+
+// Finish 请求结束时发出最终通知，之后的进度变化不再通知
+func (p *ProgressTracker) Finish() {
+	if p.notify == nil {
+		return
+	}
+	p.notifyMu.Lock()
+	defer p.notifyMu.Unlock()
+	p.mu.Lock()
+	p.finished = true
+	p.mu.Unlock()
+	p.notify(p.Snapshot())
+}
+
+// Snapshot 返回当前进度：{upload:{bytes, total, rate}, download:{...}}
+// rate为开始传输至最近一次传输数据的平均速率（字节/秒），传输结束后不再变化
+func (p *ProgressTracker) Snapshot() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return map[string]interface{}{
+		"upload":   p.upload.info(),
+		"download": p.download.info(),
+	}
+}
+
+func (t transferProgress) info() map[string]interface{} {
+	rate := 0.0
+	if elapsed := t.last.Sub(t.started).Seconds(); !t.started.IsZero() && elapsed > 0 {
+		rate = float64(t.bytes) / elapsed
+	}
+	return map[string]interface{}{
+		"bytes": t.bytes,
+		"total": t.total,
+		"rate":  rate,
+	}
+}
+
+// progressReader 统计读取字节数的请求体/响应体包装
+type progressReader struct {
+	io.ReadCloser
+	tracker *ProgressTracker
+	target  *transferProgress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if n > 0 {
+		r.tracker.add(r.target, n)
+	}
+	return n, err
+}
+
+// trackDownload 包装响应体统计下载进度
+func (p *ProgressTracker) trackDownload(res *http.Response) {
+	p.start(&p.download, res.ContentLength)
+	res.Body = &progressReader{ReadCloser: res.Body, tracker: p, target: &p.download}
+}
+
+// progressTransport 统计每一跳实际发送的请求体字节数
+type progressTransport struct {
+	base    http.RoundTripper
+	tracker *ProgressTracker
+}
+
+func (t *progressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	total := req.ContentLength
+	if req.Body == nil || req.Body == http.NoBody {
+		total = 0
+	}
+	t.tracker.start(&t.tracker.upload, total)
+	if total == 0 {
+		return t.base.RoundTrip(req)
+	}
+	tracked := req.Clone(req.Context())
+	tracked.Body = &progressReader{ReadCloser: req.Body, tracker: t.tracker, target: &t.tracker.upload}
+	return t.base.RoundTrip(tracked)
+}
@@ -0,0 +1,77 @@
+package gonethttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgressTracker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Length", "4096")
+		w.Write(make([]byte, 4096))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var notified []map[string]interface{}
+	tracker := NewProgressTracker(func(p map[string]interface{}) {
+		mu.Lock()
+		notified = append(notified, p)
+		mu.Unlock()
+	})
+	ctx := WithProgress(context.Background(), tracker)
+	if _, err := Do(ctx, &Request{
+		Method:  "POST",
+		URL:     srv.URL,
+		Headers: map[string]string{"User-Agent": "test"},
+		Body:    strings.Repeat("x", 1000),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tracker.Finish()
+
+	snap := tracker.Snapshot()
+	upload := snap["upload"].(map[string]interface{})
+	download := snap["download"].(map[string]interface{})
+	if upload["bytes"] != int64(1000) || upload["total"] != int64(1000) {
+		t.Errorf("upload = %v, want 1000/1000", upload)
+	}
+	if download["bytes"] != int64(4096) || download["total"] != int64(4096) {
+		t.Errorf("download = %v, want 4096/4096", download)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) == 0 || notified[len(notified)-1]["download"].(map[string]interface{})["bytes"] != int64(4096) {
+		t.Fatalf("final notification = %v", notified)
+	}
+}
+
+func TestProgressUploadRestartsOnRedirect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/" {
+			// 307保留方法与请求体，重发时上传进度从零计数
+			http.Redirect(w, r, "/next", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer srv.Close()
+
+	tracker := NewProgressTracker(nil)
+	if _, err := Do(WithProgress(context.Background(), tracker), &Request{
+		Method:  "POST",
+		URL:     srv.URL,
+		Headers: map[string]string{"User-Agent": "test"},
+		Body:    "12345",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if upload := tracker.Snapshot()["upload"].(map[string]interface{}); upload["bytes"] != int64(5) {
+		t.Fatalf("upload = %v, want 5 bytes for the last hop", upload)
+	}
+}
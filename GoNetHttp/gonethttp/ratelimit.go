@@ -0,0 +1,249 @@
+// ratelimit.go
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LimitRule 单条限流规则
+// Rate<=0 表示不限速，MaxConcurrent<=0 表示不限并发
+type LimitRule struct {
+	Pattern       string  `json:"pattern,omitempty"` // 主机名通配符，如 *.example.com（仅hosts列表使用）
+	Rate          float64 `json:"rate"`              // 每秒允许的请求数
+	Burst         int     `json:"burst"`             // 令牌桶容量，默认1
+	MaxConcurrent int     `json:"max_concurrent"`    // 最大同时进行的请求数
+}
+
+// LimitConfig 客户端限流配置
+//
+// 示例：
+//
+//	{"global": {"rate": 20, "burst": 20, "max_concurrent": 50},
+//	 "per_host": {"rate": 2, "burst": 2, "max_concurrent": 4},
+//	 "hosts": [{"pattern": "*.example.com", "rate": 0.5, "max_concurrent": 1}]}
+type LimitConfig struct {
+	Global  *LimitRule  `json:"global"`   // 客户端全部请求共享的限制
+	PerHost *LimitRule  `json:"per_host"` // 未命中hosts规则时每个主机各自的限制
+	Hosts   []LimitRule `json:"hosts"`    // 按主机通配符匹配的限制，先匹配先生效
+}
+
+// limiter 令牌桶与并发上限的组合
+type limiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+// newLimiter 根据规则创建限流器，规则为空时返回nil
+func newLimiter(rule *LimitRule) *limiter {
+	if rule == nil || (rule.Rate <= 0 && rule.MaxConcurrent <= 0) {
+		return nil
+	}
+	l := &limiter{}
+	if rule.Rate > 0 {
+		l.bucket = newTokenBucket(rule.Rate, rule.Burst)
+	}
+	if rule.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, rule.MaxConcurrent)
+	}
+	return l
+}
+
+// acquire 排队等待令牌与并发名额，超过ctx期限时返回错误
+func (l *limiter) acquire(ctx context.Context) error {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			l.release()
+			return err
+		}
+	}
+	return nil
+}
+
+// release 归还并发名额
+func (l *limiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// tokenBucket 令牌桶
+// 令牌可以预支为负数，排队者按预支顺序依次获得令牌
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait 获取一个令牌，必要时等待补充
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	deficit := -b.tokens
+	b.mu.Unlock()
+	if deficit <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(deficit / b.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 放弃排队，归还预支的令牌
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// hostLimits 一个客户端的全部限流状态
+type hostLimits struct {
+	cfg    LimitConfig
+	global *limiter
+	mu     sync.Mutex
+	hosts  map[string]*limiter // 按主机名懒加载
+}
+
+// newHostLimits 根据配置创建限流状态，未配置任何限制时返回nil
+func newHostLimits(cfg *LimitConfig) (*hostLimits, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	for _, rule := range cfg.Hosts {
+		if _, err := path.Match(rule.Pattern, ""); err != nil || rule.Pattern == "" {
+			return nil, fmt.Errorf("限流配置错误: 无效的主机通配符 %q", rule.Pattern)
+		}
+	}
+	return &hostLimits{
+		cfg:    *cfg,
+		global: newLimiter(cfg.Global),
+		hosts:  map[string]*limiter{},
+	}, nil
+}
+
+// forHost 获取主机对应的限流器，无限制时返回nil
+func (h *hostLimits) forHost(host string) *limiter {
+	host = strings.ToLower(host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l, ok := h.hosts[host]; ok {
+		return l
+	}
+	rule := h.cfg.PerHost
+	for i := range h.cfg.Hosts {
+		if ok, _ := path.Match(strings.ToLower(h.cfg.Hosts[i].Pattern), host); ok {
+			rule = &h.cfg.Hosts[i]
+			break
+		}
+	}
+	l := newLimiter(rule)
+	h.hosts[host] = l
+	return l
+}
+
+// queueWaitKey 在请求上下文中记录排队耗时
+type queueWaitKey struct{}
+
+// queueWait 排队耗时累计（重定向的每一跳都会排队）
+type queueWait struct {
+	mu    sync.Mutex
+	total time.Duration
+}
+
+func (q *queueWait) add(d time.Duration) {
+	q.mu.Lock()
+	q.total += d
+	q.mu.Unlock()
+}
+
+func (q *queueWait) milliseconds() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.total.Milliseconds()
+}
+
+// limitedTransport 在发送前按主机与全局规则排队的传输层
+// 并发名额在响应体关闭后才归还
+type limitedTransport struct {
+	base   http.RoundTripper
+	limits *hostLimits
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	var held []*limiter
+	releaseAll := func() {
+		for _, l := range held {
+			l.release()
+		}
+	}
+	// 先按主机排队再占用全局名额，避免在主机队列中长期占着全局并发
+	for _, l := range []*limiter{t.limits.forHost(req.URL.Hostname()), t.limits.global} {
+		if l == nil {
+			continue
+		}
+		if err := l.acquire(ctx); err != nil {
+			releaseAll()
+			// RoundTripper出错时也要关闭请求体（如body_source打开的文件）
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, fmt.Errorf("限流排队超时: %v", err)
+		}
+		held = append(held, l)
+	}
+	if qw, ok := ctx.Value(queueWaitKey{}).(*queueWait); ok {
+		qw.add(time.Since(start))
+	}
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		releaseAll()
+		return nil, err
+	}
+	res.Body = &releaseOnClose{ReadCloser: res.Body, release: releaseAll}
+	return res, nil
+}
+
+// releaseOnClose 关闭响应体时归还限流名额
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,119 @@
+package gonethttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitMaxConcurrent(t *testing.T) {
+	var active, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+	c, err := NewClient(ClientConfig{Limits: &LimitConfig{PerHost: &LimitRule{MaxConcurrent: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	var queued atomic.Int64
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			queued.Add(res.QueueWaitMs)
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", p)
+	}
+	if queued.Load() == 0 {
+		t.Fatal("queue_wait_ms is 0 for every request, want queued requests to report waiting")
+	}
+}
+
+func TestLimitQueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	c, err := NewClient(ClientConfig{Limits: &LimitConfig{Global: &LimitRule{MaxConcurrent: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	go c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	time.Sleep(50 * time.Millisecond)
+	_, err = c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, TimeoutMs: 100})
+	if code := ErrorCode(err); code != ErrQueueTimeout {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrQueueTimeout)
+	}
+
+	// 排队失败时请求体同样被关闭
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	body := &closeTracker{Reader: strings.NewReader("x")}
+	req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL, body)
+	transport := &limitedTransport{base: http.DefaultTransport, limits: c.limits}
+	if _, err := transport.RoundTrip(req); err == nil || !body.closed.Load() {
+		t.Fatalf("error = %v, body closed = %v, want a queue error with the body closed", err, body.closed.Load())
+	}
+}
+
+// closeTracker 记录是否被关闭的请求体
+type closeTracker struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestLimitRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c, err := NewClient(ClientConfig{Limits: &LimitConfig{Global: &LimitRule{Rate: 20, Burst: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 令牌桶容量1、每秒20个：第一个立即发出，其余三个各等待约50毫秒
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("4 requests at 20/s took %v, want at least 150ms", elapsed)
+	}
+}
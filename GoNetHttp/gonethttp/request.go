@@ -0,0 +1,345 @@
+// request.go
+package gonethttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Request 描述一次HTTP请求的全部参数
+// 字段与C接口PostUrlWithProxy的参数一一对应，JSON标签即C接口的请求描述，也用于请求录制
+type Request struct {
+	Method          string            `json:"method"`                      // HTTP方法
+	URL             string            `json:"url"`                         // 目标URL
+	Headers         map[string]string `json:"headers"`                     // 请求头
+	Proxy           string            `json:"proxy"`                       // 代理地址，格式为scheme://host:port
+	DisableRedirect bool              `json:"disable_redirect"`            // 是否禁用重定向
+	Body            string            `json:"body"`                        // 请求体
+	Client          int64             `json:"client,omitempty"`            // 客户端ID（Client.ID），0表示不使用
+	TimeoutMs       int64             `json:"timeout_ms,omitempty"`        // 整体超时（含限流排队），0表示不限
+	Protocol        string            `json:"protocol,omitempty"`          // 协议选项：http1/prefer_h2/h2c，默认prefer_h2
+	TLSProfile      string            `json:"tls_profile,omitempty"`       // 浏览器TLS指纹：chrome_133/firefox_120/safari_16/edge_85
+	HeaderProfile   string            `json:"header_profile,omitempty"`    // 请求头配置：chrome_desktop/safari_mobile/curl/api_client
+	UserAgentPolicy string            `json:"user_agent_policy,omitempty"` // User-Agent策略：required（默认）/optional
+	LocalAddress    string            `json:"local_address,omitempty"`     // 本地出口地址：IP或网卡名称，优先于客户端bind配置
+	Auth            *AuthConfig       `json:"auth,omitempty"`              // 认证：basic/bearer/digest
+	Sign            *SignConfig       `json:"sign,omitempty"`              // 请求签名：aws_sigv4/hmac
+	Cookies         map[string]string `json:"cookies,omitempty"`           // 随请求发送的Cookie
+	BodySource      *BodySource       `json:"body_source,omitempty"`       // 流式请求体：文件或分块写入器，替代body
+	Compress        string            `json:"compress,omitempty"`          // 请求体压缩：gzip/deflate/br/zstd
+	MaxMessageBytes int64             `json:"max_message_bytes,omitempty"` // WebSocket单条消息上限（字节），0表示默认16MB
+}
+
+// Do 发送请求并读取响应体（最多5MB）
+// 执行顺序：
+//  1. 合并客户端默认值与请求头配置，规范化并校验请求参数
+//  2. 录制/回放模式下优先交给cassette处理（录制写入失败时同时返回响应与错误）
+//  3. 其余情况直接发起网络请求
+//
+// req的字段在发送过程中会被规范化（方法转为大写、补充请求头配置等），
+// headers、auth、sign复制后再修改，不影响调用方持有的map与配置；
+// ctx取消时中止请求，可通过WithProgress携带进度跟踪器
+func Do(ctx context.Context, req *Request) (*Response, error) {
+	res, err := dispatchRequest(ctx, req)
+	if err != nil {
+		req.BodySource.abort(err)
+	}
+	return res, err
+}
+
+// Do 使用该客户端发送请求，忽略req的client字段，不修改req
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	r := *req
+	r.Client = c.id
+	return Do(ctx, &r)
+}
+
+// Open 以流式模式发送请求，收到响应头后立即返回，响应体不受5MB上限限制
+// timeout_ms只约束收到响应头之前的阶段；流式请求不经过录制/回放与HTTP缓存
+func Open(ctx context.Context, req *Request) (*StreamResponse, error) {
+	if err := prepareSpec(req); err != nil {
+		req.BodySource.abort(err)
+		return nil, err
+	}
+	res, trace, err := openResponse(ctx, req)
+	if err != nil {
+		req.BodySource.abort(err)
+		return nil, err
+	}
+	return &StreamResponse{ResponseInfo: newResponseInfo(res, trace), Body: res.Body}, nil
+}
+
+// Open 使用该客户端以流式模式发送请求，忽略req的client字段，不修改req
+func (c *Client) Open(ctx context.Context, req *Request) (*StreamResponse, error) {
+	r := *req
+	r.Client = c.id
+	return Open(ctx, &r)
+}
+
+func dispatchRequest(ctx context.Context, spec *Request) (*Response, error) {
+	if err := prepareSpec(spec); err != nil {
+		return nil, err
+	}
+	perform := func(spec *Request) (*Response, error) {
+		return performRequest(ctx, spec)
+	}
+	if c := activeCassette(); c != nil {
+		return c.handle(spec, perform)
+	}
+	return perform(spec)
+}
+
+// prepareSpec 合并客户端默认值与请求头配置，规范化并校验请求参数
+func prepareSpec(spec *Request) error {
+	spec.Method = strings.ToUpper(spec.Method)
+	// 校验时会规范化认证与签名配置并补充默认值，复制一份避免修改调用方的配置
+	if spec.Auth != nil {
+		auth := *spec.Auth
+		spec.Auth = &auth
+	}
+	if spec.Sign != nil {
+		sign := *spec.Sign
+		spec.Sign = &sign
+	}
+	c, err := lookupClient(spec.Client)
+	if err != nil {
+		return err
+	}
+	c.applyDefaults(spec)
+	if err := applyHeaderProfile(spec); err != nil {
+		return err
+	}
+	return validateSpec(spec)
+}
+
+// validateSpec 校验请求参数
+// 校验规则：
+//  1. 白名单控制HTTP方法
+//  2. 按策略要求User-Agent头
+//  3. 校验认证、签名配置、请求体来源与压缩算法
+func validateSpec(spec *Request) error {
+	// HTTP方法白名单验证
+	validMethods := map[string]bool{
+		"GET":    true,  // 允许GET
+		"POST":   true,  // 允许POST
+		"PUT":    false, // 禁用PUT
+		"DELETE": false, // 禁用DELETE
+		"PATCH":  false, // 禁用PATCH
+		"HEAD":   false, // 禁用HEAD
+	}
+	if !validMethods[spec.Method] {
+		return fmt.Errorf("无效的HTTP方法: %s", spec.Method)
+	}
+	if err := checkUserAgent(spec); err != nil {
+		return err
+	}
+	if err := spec.Auth.validate(); err != nil {
+		return err
+	}
+	if err := spec.Sign.validate(); err != nil {
+		return err
+	}
+	if err := spec.BodySource.validate(spec); err != nil {
+		return err
+	}
+	return validateCompress(spec)
+}
+
+// performRequest 发起实际的网络请求并构造返回数据
+func performRequest(ctx context.Context, spec *Request) (*Response, error) {
+	// 超时同时约束限流排队、网络请求与响应体读取
+	if spec.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(spec.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	res, trace, err := sendRequest(ctx, spec, false)
+	if err != nil {
+		return nil, err
+	}
+	// 关闭失败不影响已读取的结果
+	defer res.Body.Close()
+	// // 安全读取响应体（限制最大5MB）
+	maxBodySize := 1024 * 1024 * 5 // 5MB
+	// 使用LimitReader防止内存溢出
+	bodyBytes, errRead := io.ReadAll(io.LimitReader(res.Body, int64(maxBodySize)))
+	if errRead != nil {
+		return nil, fmt.Errorf("读取响应体失败: %v", errRead)
+	}
+	// 构造返回数据结构
+	return &Response{
+		ResponseInfo: newResponseInfo(res, trace),
+		BodySize:     len(bodyBytes),
+		Text:         string(bodyBytes),
+		Body:         bodyBytes,
+	}, nil
+}
+
+// openResponse 发送请求并在收到响应头后返回，响应体由调用方流式读取
+// timeout_ms只约束收到响应头之前的阶段；关闭响应体或取消parent时中止连接
+func openResponse(parent context.Context, spec *Request) (*http.Response, *requestTrace, error) {
+	ctx, cancel := context.WithCancel(parent)
+	var timer *time.Timer
+	if spec.TimeoutMs > 0 {
+		timer = time.AfterFunc(time.Duration(spec.TimeoutMs)*time.Millisecond, cancel)
+	}
+	res, trace, err := sendRequest(ctx, spec, true)
+	if timer != nil && !timer.Stop() {
+		if err == nil {
+			res.Body.Close()
+		}
+		err = fmt.Errorf("等待响应头超时: %d毫秒内未收到响应头", spec.TimeoutMs)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, trace, nil
+}
+
+// cancelOnClose 关闭响应体时取消对应的请求上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// requestTrace 发送过程中收集的信息
+type requestTrace struct {
+	wait       *queueWait
+	cached     *cacheStatus
+	remoteAddr string
+}
+
+// sendRequest 构造并发送请求，返回尚未读取响应体的响应
+// 参数 streaming: 流式读取的请求（事件流等）不经过HTTP缓存
+func sendRequest(ctx context.Context, spec *Request, streaming bool) (*http.Response, *requestTrace, error) {
+	bodyData := []byte(spec.Body)
+	var bodyReader io.Reader
+	if contentType, ok := spec.Headers["Content-Type"]; ok && contentType == "application/x-www-form-urlencoded" {
+		formData, err := url.ParseQuery(string(bodyData))
+		if err != nil {
+			return nil, nil, fmt.Errorf("表单数据解析失败: %v", err)
+		}
+		bodyReader = strings.NewReader(formData.Encode())
+	} else {
+		bodyReader = bytes.NewReader(bodyData)
+	}
+	c, err := lookupClient(spec.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+	trace := &requestTrace{wait: &queueWait{}, cached: &cacheStatus{}}
+	ctx = context.WithValue(ctx, queueWaitKey{}, trace.wait)
+	ctx = context.WithValue(ctx, cacheStatusKey{}, trace.cached)
+	// 记录最终连接的对端地址（直连时为解析出的目标IP，使用代理时为代理地址）
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.remoteAddr = info.Conn.RemoteAddr().String()
+		},
+	})
+	// 创建HTTP请求对象
+	req, err := http.NewRequestWithContext(ctx, spec.Method, spec.URL, bodyReader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.EqualFold(spec.Protocol, protocolH2C) && req.URL.Scheme != "http" {
+		return nil, nil, fmt.Errorf("协议选项无效: h2c仅支持http://地址")
+	}
+	// 设置请求头
+	for key, value := range spec.Headers {
+		req.Header.Add(key, value)
+	}
+	for name, value := range spec.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	transport, err := c.roundTripper(transportOptions{
+		Proxy:        spec.Proxy,
+		Protocol:     spec.Protocol,
+		TLSProfile:   spec.TLSProfile,
+		LocalAddress: spec.LocalAddress,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if spec.BodySource != nil {
+		if err := spec.BodySource.attach(req); err != nil {
+			return nil, nil, err
+		}
+	}
+	if spec.Compress != "" {
+		if err := compressRequest(req, spec.Compress, spec.BodySource != nil); err != nil {
+			if spec.BodySource != nil {
+				// 关闭body_source已打开的文件或写入器管道
+				req.Body.Close()
+			}
+			return nil, nil, err
+		}
+	}
+	// 由内到外：进度统计 → 签名 → 认证 → 缓存
+	// OAuth2令牌端点只经过拨号、TLS与限流层
+	tokenTransport := transport
+	tracker, _ := ctx.Value(progressKey{}).(*ProgressTracker)
+	if tracker != nil {
+		transport = &progressTransport{base: transport, tracker: tracker}
+	}
+	transport = newSigningTransport(transport, spec.Sign)
+	transport = c.authTransport(transport, tokenTransport, spec.Auth, req.URL)
+	if !streaming {
+		authenticated := spec.Auth != nil || spec.Sign != nil || req.Header.Get("Authorization") != "" ||
+			(c != nil && c.tokens != nil)
+		transport = c.cacheTransport(transport, authenticated)
+	}
+	// 创建HTTP客户端并禁止重定向
+	httpClient := &http.Client{
+		Transport:     transport,
+		CheckRedirect: createRedirectPolicy(spec.DisableRedirect),
+		Jar:           c.cookieJar(),
+	}
+	// 发送HTTP请求
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tracker != nil {
+		tracker.trackDownload(res)
+	}
+	return res, trace, nil
+}
+
+// createRedirectPolicy 创建HTTP重定向策略
+// 参数:
+//
+//	disable - true: 完全禁用重定向
+//	         false: 启用重定向并限制最大5次跳转
+//
+// 实现特点：
+// - 禁用时直接返回ErrUseLastResponse
+// - 启用时自动跟踪跳转链，防止重定向风暴
+func createRedirectPolicy(disable bool) func(*http.Request, []*http.Request) error {
+	if disable {
+		return func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		// 默认重定向策略（可扩展添加更多控制逻辑）
+		if len(via) >= 5 { // 示例：添加最大重定向次数限制
+			return fmt.Errorf("stopped after 5 redirects")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,243 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// echoHandler 以文本返回请求的方法、路径、请求头与请求体
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	fmt.Fprintf(w, "%s %s\nua=%s\nx-test=%s\ncontent-type=%s\nbody=%s",
+		r.Method, r.URL.RequestURI(), r.UserAgent(), r.Header.Get("X-Test"), r.Header.Get("Content-Type"), body)
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{
+		Method:  "get",
+		URL:     srv.URL + "/path?q=1",
+		Headers: map[string]string{"User-Agent": "test", "X-Test": "value"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "GET /path?q=1\nua=test\nx-test=value\ncontent-type=\nbody="
+	if res.StatusCode != http.StatusOK || res.Text != want {
+		t.Fatalf("got %d %q, want 200 %q", res.StatusCode, res.Text, want)
+	}
+	if res.BodySize != len(want) || string(res.Body) != want || res.Protocol != "HTTP/1.1" || res.Cache != cacheNetwork {
+		t.Fatalf("unexpected response fields: %+v", res.ResponseInfo)
+	}
+	if res.RemoteAddr != srv.Listener.Addr().String() {
+		t.Fatalf("remote_addr = %s, want %s", res.RemoteAddr, srv.Listener.Addr())
+	}
+}
+
+func TestDoPostForm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{
+		Method:  "POST",
+		URL:     srv.URL,
+		Headers: map[string]string{"User-Agent": "test", "Content-Type": "application/x-www-form-urlencoded"},
+		Body:    "b=2&a=1 x",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 表单请求体按url.Values重新编码（键排序、转义）
+	if !strings.HasSuffix(res.Text, "body=a=1+x&b=2") {
+		t.Fatalf("got %q", res.Text)
+	}
+}
+
+func TestDoResponseBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 6*1024*1024))
+	}))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BodySize != 5*1024*1024 {
+		t.Fatalf("body_size = %d, want 5MB", res.BodySize)
+	}
+}
+
+// redirectChain 依次重定向/0 → /1 → … → /n，每一跳设置一个Cookie
+func redirectChain(n int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		http.SetCookie(w, &http.Cookie{Name: "hop" + strconv.Itoa(i), Value: "1"})
+		if i < n {
+			http.Redirect(w, r, "/"+strconv.Itoa(i+1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "done")
+	})
+}
+
+func TestDoRedirectHistory(t *testing.T) {
+	srv := httptest.NewServer(redirectChain(2))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL + "/0", Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Text != "done" {
+		t.Fatalf("got %d %q, want 200 done", res.StatusCode, res.Text)
+	}
+	wantRedirects := []string{srv.URL + "/2", srv.URL + "/1", srv.URL + "/0"}
+	if !slices.Equal(res.Redirects, wantRedirects) {
+		t.Fatalf("redirects = %v, want %v", res.Redirects, wantRedirects)
+	}
+	if len(res.Hops) != 3 {
+		t.Fatalf("hops = %+v, want 3", res.Hops)
+	}
+	for i, hop := range res.Hops {
+		wantStatus := http.StatusFound
+		if i == 2 {
+			wantStatus = http.StatusOK
+		}
+		if hop.URL != srv.URL+"/"+strconv.Itoa(i) || hop.StatusCode != wantStatus ||
+			len(hop.Cookies) != 1 || hop.Cookies[0].Name != "hop"+strconv.Itoa(i) {
+			t.Errorf("hop %d = %+v", i, hop)
+		}
+	}
+}
+
+func TestDoRedirectLimit(t *testing.T) {
+	srv := httptest.NewServer(redirectChain(10))
+	defer srv.Close()
+
+	_, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL + "/0", Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrRedirectExceed {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrRedirectExceed)
+	}
+}
+
+func TestDoDisableRedirect(t *testing.T) {
+	srv := httptest.NewServer(redirectChain(2))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{
+		Method:          "GET",
+		URL:             srv.URL + "/0",
+		Headers:         map[string]string{"User-Agent": "test"},
+		DisableRedirect: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusFound || res.Headers.Get("Location") != "/1" || len(res.Redirects) != 1 {
+		t.Fatalf("got %d location=%q redirects=%v, want the first 302", res.StatusCode, res.Headers.Get("Location"), res.Redirects)
+	}
+}
+
+func TestDoValidationErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name string
+		req  Request
+		code int
+	}{
+		{"method", Request{Method: "DELETE", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}}, ErrInvalidMethod},
+		{"user agent", Request{Method: "GET", URL: srv.URL}, ErrMissingUserAgent},
+		{"client", Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, Client: -1}, ErrUnknownClient},
+		{"proxy", Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, Proxy: "://bad"}, ErrProxyConfig},
+		{"h2c", Request{Method: "GET", URL: "https://localhost", Headers: map[string]string{"User-Agent": "test"}, Protocol: "h2c"}, ErrProtocolConfig},
+		{"auth", Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, Auth: &AuthConfig{Type: "ntlm"}}, ErrAuthConfig},
+		{"compress", Request{Method: "POST", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, Body: "x", Compress: "lz4"}, ErrCompressConfig},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			_, err := Do(context.Background(), &req)
+			if code := ErrorCode(err); code != tc.code {
+				t.Fatalf("error code = %d (%v), want %d", code, err, tc.code)
+			}
+		})
+	}
+}
+
+func TestDoNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(echoHandler))
+	addr := srv.URL
+	srv.Close()
+
+	_, err := Do(context.Background(), &Request{Method: "GET", URL: addr, Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrNetwork {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrNetwork)
+	}
+}
+
+func TestDoTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	_, err := Do(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}, TimeoutMs: 100})
+	if code := ErrorCode(err); code != ErrNetwork {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrNetwork)
+	}
+}
+
+func TestOpenStreamsBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 6*1024*1024))
+	}))
+	defer srv.Close()
+
+	res, err := Open(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	n, err := io.Copy(io.Discard, res.Body)
+	if err != nil || n != 6*1024*1024 {
+		t.Fatalf("read %d bytes (%v), want 6MB without the Do limit", n, err)
+	}
+}
+
+func TestDoCredentialsStayOnOrigin(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 端口不同即为不同的源
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	res, err := Do(context.Background(), &Request{
+		Method:  "GET",
+		URL:     srv.URL,
+		Headers: map[string]string{"User-Agent": "test"},
+		Auth:    &AuthConfig{Type: "bearer", Token: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "" {
+		t.Fatalf("other origin received Authorization %q", res.Text)
+	}
+}
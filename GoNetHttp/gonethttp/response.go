@@ -0,0 +1,165 @@
+// response.go
+package gonethttp
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// ResponseInfo 与响应体无关的响应信息
+// JSON标签即C接口返回的result字段，录制文件中的记录也按此结构保存
+type ResponseInfo struct {
+	Status        string      `json:"status"`             // 完整状态字符串（如"200 OK"）
+	StatusCode    int         `json:"status_code"`        // 状态码（如200）
+	Protocol      string      `json:"protocol"`           // 协议版本（如HTTP/1.1）
+	Headers       http.Header `json:"headers"`            // 响应头
+	ContentLength int64       `json:"content_length"`     // 声明的响应体长度
+	Cookies       []Cookie    `json:"cookies"`            // Cookies
+	Server        string      `json:"server"`             // 服务器信息
+	ContentType   string      `json:"content_type"`       // 内容类型
+	Date          string      `json:"date"`               // 响应日期
+	Redirects     []string    `json:"redirects"`          // 重定向历史（倒序，最新请求在前）
+	Hops          []Hop       `json:"hops"`               // 重定向链中每个响应的状态码与Set-Cookie（按请求顺序）
+	QueueWaitMs   int64       `json:"queue_wait_ms"`      // 限流排队耗时（毫秒）
+	ALPN          string      `json:"alpn"`               // TLS协商的ALPN协议（明文连接为空）
+	RemoteAddr    string      `json:"remote_addr"`        // 实际连接的对端地址
+	Cache         string      `json:"cache"`              // 缓存结果：hit/revalidated/network
+	Cassette      string      `json:"cassette,omitempty"` // 来自录制文件时为replay
+}
+
+// Response 已读取响应体的响应
+type Response struct {
+	ResponseInfo
+	BodySize int    `json:"body_size"` // 实际读取的字节数
+	Text     string `json:"body"`      // 响应体内容
+	Body     []byte `json:"byte"`      // 字节数组（JSON中为base64）
+}
+
+// StreamResponse 流式模式的响应，响应体由调用方读取
+// 读取完毕或放弃读取后都必须关闭Body以释放连接
+type StreamResponse struct {
+	ResponseInfo
+	Body io.ReadCloser
+}
+
+// Cookie 序列化友好的Cookie，保留全部属性，便于调用方持久化与回放会话
+//
+// 示例：
+//
+//	{"name": "session", "value": "abc123", "domain": ".example.com", "path": "/", "expires": "2026-01-02T15:04:05Z",
+//	 "max_age": 3600, "secure": true, "http_only": true, "same_site": "Lax", "partitioned": false, "raw": "session=abc123; ..."}
+type Cookie struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Domain      string `json:"domain"`
+	Path        string `json:"path"`
+	Expires     string `json:"expires"` // RFC 3339时间，未设置时为空字符串
+	MaxAge      int    `json:"max_age"` // 未设置时为0，Max-Age<=0（要求删除）时为-1
+	Secure      bool   `json:"secure"`
+	HttpOnly    bool   `json:"http_only"`
+	SameSite    string `json:"same_site"` // Lax/Strict/None，未设置时为空字符串
+	Partitioned bool   `json:"partitioned"`
+	Raw         string `json:"raw"` // 原始Set-Cookie值
+}
+
+// Hop 重定向链中的一个响应
+//
+// 示例：
+//
+//	{"url": "https://a.example/login", "status_code": 302, "set_cookie": ["sid=1; Path=/; HttpOnly"], "cookies": [...]}
+type Hop struct {
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code"`
+	SetCookie  []string `json:"set_cookie"`
+	Cookies    []Cookie `json:"cookies"`
+}
+
+// newResponseInfo 构造与响应体无关的返回字段
+func newResponseInfo(res *http.Response, trace *requestTrace) ResponseInfo {
+	return ResponseInfo{
+		Status:        res.Status,
+		StatusCode:    res.StatusCode,
+		Protocol:      res.Proto,
+		Headers:       convertHeaders(res.Header),
+		ContentLength: res.ContentLength,
+		Cookies:       convertCookies(res.Cookies()),
+		Server:        res.Header.Get("Server"),
+		ContentType:   res.Header.Get("Content-Type"),
+		Date:          res.Header.Get("Date"),
+		Redirects:     getRedirectHistory(res),
+		Hops:          responseHops(res),
+		QueueWaitMs:   trace.wait.milliseconds(),
+		ALPN:          negotiatedProtocol(res),
+		RemoteAddr:    trace.remoteAddr,
+		Cache:         trace.cached.get(),
+	}
+}
+
+// convertHeaders 复制响应头，避免返回值与连接上的响应共享
+func convertHeaders(h http.Header) http.Header {
+	headers := make(http.Header, len(h))
+	for k, v := range h {
+		headers[k] = v
+	}
+	return headers
+}
+
+// convertCookies 转换http.Cookie为序列化友好的格式
+func convertCookies(cookies []*http.Cookie) []Cookie {
+	var result []Cookie
+	for _, c := range cookies {
+		expires := ""
+		if !c.Expires.IsZero() {
+			expires = c.Expires.UTC().Format(time.RFC3339)
+		}
+		result = append(result, Cookie{
+			Name:        c.Name,
+			Value:       c.Value,
+			Domain:      c.Domain,
+			Path:        c.Path,
+			Expires:     expires,
+			MaxAge:      c.MaxAge,
+			Secure:      c.Secure,
+			HttpOnly:    c.HttpOnly,
+			SameSite:    sameSiteName(c.SameSite),
+			Partitioned: c.Partitioned,
+			Raw:         c.Raw,
+		})
+	}
+	return result
+}
+
+// getRedirectHistory 获取重定向历史记录
+// 参数 res: 最终响应对象
+// 返回值: 历史URL列表（倒序，最新请求在前）
+func getRedirectHistory(res *http.Response) []string {
+	var urls []string
+	for res != nil {
+		urls = append(urls, res.Request.URL.String())
+		res = res.Request.Response
+	}
+	return urls
+}
+
+// responseHops 按请求顺序返回重定向链中每个响应的状态码与Set-Cookie
+func responseHops(res *http.Response) []Hop {
+	var hops []Hop
+	for r := res; r != nil; r = r.Request.Response {
+		setCookie := r.Header.Values("Set-Cookie")
+		if setCookie == nil {
+			setCookie = []string{}
+		}
+		hops = append(hops, Hop{
+			URL:        r.Request.URL.String(),
+			StatusCode: r.StatusCode,
+			SetCookie:  setCookie,
+			Cookies:    convertCookies(r.Cookies()),
+		})
+	}
+	// 由最终响应向前遍历得到的是倒序
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
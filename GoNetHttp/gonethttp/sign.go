@@ -0,0 +1,411 @@
+// sign.go
+package gonethttp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名方式
+const (
+	signAWSv4 = "aws_sigv4"
+	signHMAC  = "hmac"
+)
+
+// SignConfig 请求签名配置（请求描述或NewClient配置中的sign字段）
+// 签名在请求头与请求体最终确定后、每次发送前计算，重定向与认证重发时重新签名
+//
+// 示例：
+//
+//	{"type": "aws_sigv4", "access_key": "AK", "secret_key": "SK", "region": "us-east-1", "service": "s3"}
+//	{"type": "hmac", "secret": "key", "algorithm": "sha256", "header": "X-Signature",
+//	 "components": ["method", "path", "query", "timestamp", "body_sha256"], "timestamp_header": "X-Timestamp"}
+type SignConfig struct {
+	Type string `json:"type"` // aws_sigv4/hmac
+
+	// AWS Signature V4
+	AccessKey       string `json:"access_key,omitempty"`
+	SecretKey       string `json:"secret_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`    // 临时凭证，放入X-Amz-Security-Token
+	Region          string `json:"region,omitempty"`           // 默认us-east-1
+	Service         string `json:"service,omitempty"`          // 服务名，如s3、execute-api
+	UnsignedPayload bool   `json:"unsigned_payload,omitempty"` // 不对请求体计算哈希（S3的UNSIGNED-PAYLOAD）
+
+	// 通用HMAC
+	Secret          string   `json:"secret,omitempty"`           // 密钥
+	SecretEncoding  string   `json:"secret_encoding,omitempty"`  // 密钥编码：raw（默认）/hex/base64
+	Algorithm       string   `json:"algorithm,omitempty"`        // sha256（默认）/sha1/sha512/md5
+	Header          string   `json:"header,omitempty"`           // 签名写入的请求头，默认X-Signature
+	Prefix          string   `json:"prefix,omitempty"`           // 签名值前缀，如"HMAC "
+	Encoding        string   `json:"encoding,omitempty"`         // 签名编码：hex（默认）/base64
+	Components      []string `json:"components,omitempty"`       // 参与签名的内容，按顺序拼接，见validComponent
+	Separator       *string  `json:"separator,omitempty"`        // 拼接分隔符，默认换行
+	TimestampHeader string   `json:"timestamp_header,omitempty"` // 时间戳请求头，components含timestamp时默认X-Timestamp
+	TimestampFormat string   `json:"timestamp_format,omitempty"` // unix（默认）/unix_ms/rfc3339
+}
+
+// validate 校验签名配置并补全默认值
+func (s *SignConfig) validate() error {
+	if s == nil {
+		return nil
+	}
+	s.Type = strings.ToLower(s.Type)
+	switch s.Type {
+	case signAWSv4:
+		if s.AccessKey == "" || s.SecretKey == "" || s.Service == "" {
+			return fmt.Errorf("签名配置无效: aws_sigv4需要access_key、secret_key与service")
+		}
+		if s.Region == "" {
+			s.Region = "us-east-1"
+		}
+	case signHMAC:
+		if _, err := s.hmacKey(); err != nil {
+			return err
+		}
+		if _, err := hashFunc(s.Algorithm); err != nil {
+			return err
+		}
+		switch s.Encoding {
+		case "", "hex", "base64":
+		default:
+			return fmt.Errorf("签名配置无效: 未知的签名编码 %s", s.Encoding)
+		}
+		switch s.TimestampFormat {
+		case "", "unix", "unix_ms", "rfc3339":
+		default:
+			return fmt.Errorf("签名配置无效: 未知的时间戳格式 %s", s.TimestampFormat)
+		}
+		if len(s.Components) == 0 {
+			s.Components = []string{"method", "path", "query", "body"}
+		}
+		for _, component := range s.Components {
+			if !validComponent(component) {
+				return fmt.Errorf("签名配置无效: 未知的签名内容 %s", component)
+			}
+			if component == "timestamp" && s.TimestampHeader == "" {
+				s.TimestampHeader = "X-Timestamp"
+			}
+		}
+		if s.Header == "" {
+			s.Header = "X-Signature"
+		}
+	default:
+		return fmt.Errorf("签名配置无效: 未知的签名方式 %s", s.Type)
+	}
+	return nil
+}
+
+// hmacKey 按secret_encoding解码密钥
+func (s *SignConfig) hmacKey() ([]byte, error) {
+	if s.Secret == "" {
+		return nil, fmt.Errorf("签名配置无效: hmac需要secret")
+	}
+	switch s.SecretEncoding {
+	case "", "raw":
+		return []byte(s.Secret), nil
+	case "hex":
+		key, err := hex.DecodeString(s.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("签名配置无效: secret不是有效的hex")
+		}
+		return key, nil
+	case "base64":
+		key, err := base64.StdEncoding.DecodeString(s.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("签名配置无效: secret不是有效的base64")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("签名配置无效: 未知的密钥编码 %s", s.SecretEncoding)
+	}
+}
+
+// hashFunc 按名称返回哈希算法
+func hashFunc(name string) (func() hash.Hash, error) {
+	switch strings.ToLower(name) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "md5":
+		return md5.New, nil
+	default:
+		return nil, fmt.Errorf("签名配置无效: 不支持的算法 %s", name)
+	}
+}
+
+// validComponent 判断签名内容是否受支持
+// method、path（已编码路径）、query（按键排序的查询串）、host、url（完整URL）、
+// body（原始请求体）、body_sha256（请求体SHA-256的hex）、timestamp、header:<名称>
+func validComponent(component string) bool {
+	switch component {
+	case "method", "path", "query", "host", "url", "body", "body_sha256", "timestamp":
+		return true
+	}
+	name, ok := strings.CutPrefix(component, "header:")
+	return ok && name != ""
+}
+
+// signingTransport 在每次发送前对请求签名
+type signingTransport struct {
+	base http.RoundTripper
+	sign *SignConfig
+	now  func() time.Time
+}
+
+// newSigningTransport 在传输层外包装签名，未配置签名时原样返回
+func newSigningTransport(base http.RoundTripper, sign *SignConfig) http.RoundTripper {
+	if sign == nil {
+		return base
+	}
+	return &signingTransport{base: base, sign: sign, now: time.Now}
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 签名不涉及请求体时不读取，文件等流式请求体保持流式发送
+	var body []byte
+	if t.sign.needsBody() {
+		var err error
+		if body, err = requestBody(req); err != nil {
+			return nil, err
+		}
+	}
+	req = req.Clone(req.Context())
+	if t.sign.Type == signAWSv4 {
+		t.signAWSv4(req, body)
+	} else {
+		t.signHMAC(req, body)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// needsBody 签名是否需要读取请求体
+func (s *SignConfig) needsBody() bool {
+	if s.Type == signAWSv4 {
+		return !s.UnsignedPayload
+	}
+	return slices.Contains(s.Components, "body") || slices.Contains(s.Components, "body_sha256")
+}
+
+// requestBody 读取请求体副本用于计算签名，不消耗原请求体
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("签名配置无效: 请求体不可重复读取，无法签名")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// signAWSv4 按AWS Signature Version 4签名
+func (t *signingTransport) signAWSv4(req *http.Request, body []byte) {
+	s := t.sign
+	now := t.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := "UNSIGNED-PAYLOAD"
+	if !s.UnsignedPayload {
+		payloadHash = hexSHA256(body)
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" {
+		// S3要求声明请求体哈希
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	// 参与签名的请求头：host、content-type、content-md5与全部x-amz-*
+	headers := map[string]string{"host": requestHost(req)}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "content-md5" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	// S3对路径只编码一次，其余服务对已编码路径再编码一次
+	path := awsEscape(req.URL.Path, false)
+	if s.Service != "s3" {
+		path = awsEscape(path, false)
+	}
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query(), true),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+	key := []byte("AWS4" + s.SecretKey)
+	for _, part := range []string{date, s.Region, s.Service, "aws4_request"} {
+		key = hmacSum(sha256.New, key, []byte(part))
+	}
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, []byte(stringToSign)))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+// signHMAC 按配置拼接签名内容并计算HMAC
+func (t *signingTransport) signHMAC(req *http.Request, body []byte) {
+	s := t.sign
+	var timestamp string
+	if s.TimestampHeader != "" {
+		now := t.now()
+		switch s.TimestampFormat {
+		case "unix_ms":
+			timestamp = strconv.FormatInt(now.UnixMilli(), 10)
+		case "rfc3339":
+			timestamp = now.UTC().Format(time.RFC3339)
+		default:
+			timestamp = strconv.FormatInt(now.Unix(), 10)
+		}
+		req.Header.Set(s.TimestampHeader, timestamp)
+	}
+	parts := make([]string, len(s.Components))
+	for i, component := range s.Components {
+		switch component {
+		case "method":
+			parts[i] = req.Method
+		case "path":
+			parts[i] = req.URL.EscapedPath()
+		case "query":
+			parts[i] = canonicalQuery(req.URL.Query(), false)
+		case "host":
+			parts[i] = requestHost(req)
+		case "url":
+			parts[i] = req.URL.String()
+		case "body":
+			parts[i] = string(body)
+		case "body_sha256":
+			parts[i] = hexSHA256(body)
+		case "timestamp":
+			parts[i] = timestamp
+		default:
+			parts[i] = req.Header.Get(strings.TrimPrefix(component, "header:"))
+		}
+	}
+	separator := "\n"
+	if s.Separator != nil {
+		separator = *s.Separator
+	}
+	newHash, _ := hashFunc(s.Algorithm)
+	key, _ := s.hmacKey()
+	sum := hmacSum(newHash, key, []byte(strings.Join(parts, separator)))
+	signature := hex.EncodeToString(sum)
+	if s.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	req.Header.Set(s.Header, s.Prefix+signature)
+}
+
+// requestHost 返回请求实际发送的Host（省略默认端口）
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	h, port, err := net.SplitHostPort(host)
+	if err != nil || !((port == "80" && req.URL.Scheme == "http") || (port == "443" && req.URL.Scheme == "https")) {
+		return host
+	}
+	if strings.Contains(h, ":") {
+		return "[" + h + "]"
+	}
+	return h
+}
+
+// canonicalQuery 按键、值排序并编码查询参数
+// 参数 strict: 按AWS规则编码（空格为%20，仅保留A-Za-z0-9-_.~）
+// AWS要求先按编码后的键排序、键相同再按值排序，不能对拼接后的"键=值"排序：
+// 如a-b与a=1，'='大于'-'、'.'与数字，拼接后排序会得到不同的顺序
+func canonicalQuery(query url.Values, strict bool) string {
+	if !strict {
+		return query.Encode()
+	}
+	var pairs [][2]string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsEscape(key, true), awsEscape(value, true)})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b [2]string) int {
+		if c := strings.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(a[1], b[1])
+	})
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p[0]+"="+p[1])
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape 按RFC 3986编码，encodeSlash为false时保留路径分隔符
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSum(newHash func() hash.Hash, key, data []byte) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
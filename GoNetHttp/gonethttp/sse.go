@@ -0,0 +1,292 @@
+// sse.go
+package gonethttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetry 服务器未指定retry时的重连间隔
+const defaultRetry = 3 * time.Second
+
+// maxEventBytes 单行与单个事件data的上限，超过时结束事件流，避免服务器不发送换行时无限占用内存
+const maxEventBytes = 16 * 1024 * 1024
+
+// Event 解析出的一条事件
+type Event struct {
+	ID    string `json:"id"`              // 事件ID（未指定时沿用上一条的ID）
+	Event string `json:"event"`           // 事件类型，默认message
+	Data  string `json:"data"`            // 多行data以换行拼接
+	Retry int64  `json:"retry,omitempty"` // 事件中携带的重连间隔（毫秒）
+}
+
+// EventStream 一个事件流连接，断开后自动重连
+type EventStream struct {
+	spec     Request
+	cancel   context.CancelFunc
+	events   chan Event    // 读取协程写入，Next取出
+	done     chan struct{} // 事件流结束（关闭或连接失败）后关闭
+	err      error         // 结束原因，正常关闭时为nil
+	response ResponseInfo  // 首次连接的响应信息
+
+	lastEventID string
+	retry       time.Duration
+	connected   chan ResponseInfo // 首次连接结果（响应信息），只写入一次
+}
+
+// OpenEventStream 打开Server-Sent Events事件流
+// timeout_ms限制每次建立连接（收到响应头）的时间；ctx约束整个事件流，结束后停止读取与重连，Next返回ctx的错误
+//
+// 首次连接失败（网络错误、非200状态码或Content-Type不是text/event-stream）时直接返回错误；
+// 之后连接断开会按Last-Event-ID与服务器指定的retry间隔自动重连，不再使用时调用Close；
+// 单行或单个事件的data超过16MB时事件流以错误结束
+func OpenEventStream(ctx context.Context, req *Request) (*EventStream, error) {
+	spec := *req
+	if err := prepareSpec(&spec); err != nil {
+		return nil, err
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	es := &EventStream{
+		spec:      spec,
+		cancel:    cancel,
+		events:    make(chan Event, 64),
+		done:      make(chan struct{}),
+		retry:     defaultRetry,
+		connected: make(chan ResponseInfo, 1),
+	}
+	es.lastEventID = lookupHeader(spec.Headers, "Last-Event-ID")
+	go es.run(ctx, parent)
+	select {
+	case es.response = <-es.connected:
+		return es, nil
+	case <-es.done:
+		select {
+		case es.response = <-es.connected:
+			// 首次连接成功后服务器立即结束了事件流
+			return es, nil
+		default:
+		}
+		return nil, es.err
+	}
+}
+
+// Response 首次连接的响应信息
+func (es *EventStream) Response() ResponseInfo {
+	return es.response
+}
+
+// Next 取出下一条事件，ctx结束时返回ctx.Err()
+// 事件流已结束且事件已取完时返回io.EOF，因错误结束时返回该错误
+func (es *EventStream) Next(ctx context.Context) (Event, error) {
+	// 优先返回已缓冲的事件，事件流结束后也能取完
+	select {
+	case ev := <-es.events:
+		return ev, nil
+	default:
+	}
+	select {
+	case ev := <-es.events:
+		return ev, nil
+	case <-es.done:
+		select {
+		case ev := <-es.events:
+			return ev, nil
+		default:
+		}
+		if es.err != nil {
+			return Event{}, es.err
+		}
+		return Event{}, io.EOF
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	}
+}
+
+// Close 关闭事件流并等待读取协程退出
+func (es *EventStream) Close() {
+	es.cancel()
+	<-es.done
+}
+
+// run 连接、读取与重连循环
+// 参数 parent: 调用方传入的上下文，其结束（而非Close）导致事件流结束时记录为结束原因
+func (es *EventStream) run(ctx, parent context.Context) {
+	defer close(es.done)
+	defer func() {
+		if es.err == nil {
+			es.err = parent.Err()
+		}
+	}()
+	first := true
+	for {
+		body, info, retryable, err := es.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && (first || !retryable) {
+			// 首次连接失败或服务器明确拒绝（状态码、类型错误）时不再重连
+			es.err = err
+			return
+		}
+		if first {
+			es.connected <- info
+			first = false
+		}
+		if body == nil && err == nil {
+			// 服务器返回204，要求不再重连
+			return
+		}
+		if body != nil {
+			stop, errRead := es.read(ctx, body)
+			if errRead != nil {
+				// 超出上限的内容重连后仍会出现，不再重连
+				es.err = errRead
+				return
+			}
+			if stop {
+				return
+			}
+		}
+		select {
+		case <-time.After(es.retry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// connect 发起一次连接，返回事件流的响应体
+// 服务器返回204表示不要再重连，此时响应体与错误均为nil
+// 返回值 retryable: 网络错误可重连，状态码或类型错误不可重连
+func (es *EventStream) connect(ctx context.Context) (io.ReadCloser, ResponseInfo, bool, error) {
+	spec := es.spec
+	spec.Headers = map[string]string{}
+	for k, v := range es.spec.Headers {
+		if !strings.EqualFold(k, "Last-Event-ID") {
+			spec.Headers[k] = v
+		}
+	}
+	if !hasHeader(spec.Headers, "Accept") {
+		spec.Headers["Accept"] = "text/event-stream"
+	}
+	spec.Headers["Cache-Control"] = "no-cache"
+	if es.lastEventID != "" {
+		spec.Headers["Last-Event-ID"] = es.lastEventID
+	}
+	res, trace, err := openResponse(ctx, &spec)
+	if err != nil {
+		return nil, ResponseInfo{}, true, err
+	}
+	info := newResponseInfo(res, trace)
+	if res.StatusCode == http.StatusNoContent {
+		res.Body.Close()
+		return nil, info, false, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if res.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+		res.Body.Close()
+		return nil, ResponseInfo{}, false, fmt.Errorf("事件流连接失败: %s (Content-Type: %s)", res.Status, res.Header.Get("Content-Type"))
+	}
+	return res.Body, info, false, nil
+}
+
+// read 按WHATWG规范解析事件流直到连接断开
+// 返回值为true表示事件流已被关闭，不再重连；单行或事件超过上限时返回错误
+func (es *EventStream) read(ctx context.Context, body io.ReadCloser) (bool, error) {
+	defer body.Close()
+	reader := bufio.NewReader(body)
+	var data strings.Builder
+	var ev Event
+	hasData := false
+	for {
+		line, err := readLine(reader, maxEventBytes)
+		if errors.Is(err, errLineTooLong) {
+			return true, fmt.Errorf("事件流读取失败: 单行超过%d字节上限", maxEventBytes)
+		}
+		if err != nil {
+			return ctx.Err() != nil, nil
+		}
+		if line == "" {
+			// 空行派发事件
+			if hasData {
+				ev.ID = es.lastEventID
+				ev.Data = strings.TrimSuffix(data.String(), "\n")
+				if ev.Event == "" {
+					ev.Event = "message"
+				}
+				select {
+				case es.events <- ev:
+				case <-ctx.Done():
+					return true, nil
+				}
+			}
+			data.Reset()
+			ev = Event{}
+			hasData = false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // 注释
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			if data.Len()+len(value) >= maxEventBytes {
+				return true, fmt.Errorf("事件流读取失败: 事件data超过%d字节上限", maxEventBytes)
+			}
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				es.lastEventID = value
+			}
+		case "retry":
+			if ms, errRetry := strconv.ParseInt(value, 10, 64); errRetry == nil && ms >= 0 {
+				es.retry = time.Duration(ms) * time.Millisecond
+				ev.Retry = ms
+			}
+		}
+	}
+}
+
+// errLineTooLong 行长度超过上限
+var errLineTooLong = errors.New("line too long")
+
+// readLine 读取一行，行尾可以是CRLF、LF或CR
+// 参数 limit: 行长度上限（不含行尾），超过时返回errLineTooLong
+func readLine(r *bufio.Reader, limit int) (string, error) {
+	var b strings.Builder
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b.Len() >= limit && c != '\n' && c != '\r' {
+			return "", errLineTooLong
+		}
+		switch c {
+		case '\n':
+			return b.String(), nil
+		case '\r':
+			if next, errPeek := r.Peek(1); errPeek == nil && next[0] == '\n' {
+				r.ReadByte()
+			}
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gonethttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseHandler 发送给定的原始事件流内容后保持连接，直到客户端断开
+func sseHandler(payload func(w io.Writer)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		payload(w)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+}
+
+func TestEventStream(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(func(w io.Writer) {
+		fmt.Fprint(w, ": comment\r\nid: 1\r\nevent: update\r\ndata: a\r\ndata: b\r\n\r\ndata: c\rretry: 500\r\n\r\n")
+	}))
+	defer srv.Close()
+
+	es, err := OpenEventStream(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, want := range []Event{
+		{ID: "1", Event: "update", Data: "a\nb"},
+		{ID: "1", Event: "message", Data: "c", Retry: 500},
+	} {
+		ev, err := es.Next(ctx)
+		if err != nil || ev != want {
+			t.Fatalf("event = %+v, %v; want %+v", ev, err, want)
+		}
+	}
+}
+
+func TestEventStreamLineLimit(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(func(w io.Writer) {
+		fmt.Fprint(w, "data: ok\n\n")
+		// 不带换行的超长行
+		bw := bufio.NewWriter(w)
+		bw.WriteString("data: ")
+		bw.WriteString(strings.Repeat("x", maxEventBytes))
+		bw.Flush()
+	}))
+	defer srv.Close()
+
+	es, err := OpenEventStream(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if ev, err := es.Next(ctx); err != nil || ev.Data != "ok" {
+		t.Fatalf("first event = %+v, %v", ev, err)
+	}
+	_, err = es.Next(ctx)
+	if code := ErrorCode(err); code != ErrEventStream {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrEventStream)
+	}
+}
+
+func TestEventStreamDataLimit(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(func(w io.Writer) {
+		// 每行都在上限以内，但同一事件累计的data超过上限
+		bw := bufio.NewWriter(w)
+		line := "data: " + strings.Repeat("x", 1024*1024) + "\n"
+		for i := 0; i <= maxEventBytes/(1024*1024); i++ {
+			bw.WriteString(line)
+		}
+		bw.Flush()
+	}))
+	defer srv.Close()
+
+	es, err := OpenEventStream(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = es.Next(ctx)
+	if code := ErrorCode(err); code != ErrEventStream {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrEventStream)
+	}
+}
+
+func TestEventStreamContext(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(func(w io.Writer) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	es, err := OpenEventStream(ctx, &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	cancel()
+	wait, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if _, err := es.Next(wait); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Next after cancel = %v, want context.Canceled", err)
+	}
+
+	// 已取消的ctx不发起连接
+	if _, err := OpenEventStream(ctx, &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("open with cancelled ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestEventStreamClose(t *testing.T) {
+	srv := httptest.NewServer(sseHandler(func(w io.Writer) {}))
+	defer srv.Close()
+
+	es, err := OpenEventStream(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es.Close()
+	if _, err := es.Next(context.Background()); err != io.EOF {
+		t.Fatalf("Next after Close = %v, want io.EOF", err)
+	}
+}
+
+func TestEventStreamRejectsNonEventStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{}")
+	}))
+	defer srv.Close()
+
+	_, err := OpenEventStream(context.Background(), &Request{Method: "GET", URL: srv.URL, Headers: map[string]string{"User-Agent": "test"}})
+	if code := ErrorCode(err); code != ErrEventStream {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrEventStream)
+	}
+}
@@ -0,0 +1,407 @@
+// tlsprofile.go
+package gonethttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	utls "github.com/refraction-networking/utls"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/net/http2"
+)
+
+// tlsProfile 浏览器TLS指纹模拟配置
+// ClientHello（密码套件、扩展顺序、曲线、ALPN）由uTLS预设生成；
+// 协商出HTTP/2时由http2Conn按浏览器改写SETTINGS（顺序与取值）与伪头部顺序，
+// 连接级WINDOW_UPDATE增量通过传输层配置设置；浏览器发送的PRIORITY帧不模拟
+type tlsProfile struct {
+	name              string
+	helloID           utls.ClientHelloID
+	http2Settings     []http2.Setting // 连接建立时发送的SETTINGS，按浏览器的顺序
+	connectionWindow  uint32          // 连接级WINDOW_UPDATE增量
+	pseudoHeaderOrder string          // 伪头部顺序（m,a,s,p分别为:method、:authority、:scheme、:path）
+	expectedJA4       string          // 该浏览器版本公开的JA4指纹，用于本地自检
+}
+
+// tlsProfiles 内置的浏览器指纹模拟配置，版本固定
+var tlsProfiles = map[string]*tlsProfile{
+	"chrome_133": {
+		name:    "chrome_133",
+		helloID: utls.HelloChrome_133,
+		http2Settings: []http2.Setting{
+			{ID: http2.SettingHeaderTableSize, Val: 65536},
+			{ID: http2.SettingEnablePush, Val: 0},
+			{ID: http2.SettingInitialWindowSize, Val: 6291456},
+			{ID: http2.SettingMaxHeaderListSize, Val: 262144},
+		},
+		connectionWindow:  15663105,
+		pseudoHeaderOrder: "m,a,s,p",
+		expectedJA4:       "t13d1516h2_8daaf6152771_d8a2da3f94cd",
+	},
+	"firefox_120": {
+		name:    "firefox_120",
+		helloID: utls.HelloFirefox_120,
+		http2Settings: []http2.Setting{
+			{ID: http2.SettingHeaderTableSize, Val: 65536},
+			{ID: http2.SettingEnablePush, Val: 0},
+			{ID: http2.SettingInitialWindowSize, Val: 131072},
+			{ID: http2.SettingMaxFrameSize, Val: 16384},
+		},
+		connectionWindow:  12517377,
+		pseudoHeaderOrder: "m,p,a,s",
+		expectedJA4:       "t13d1715h2_5b57614c22b0_5c2c66f702b0",
+	},
+	"safari_16": {
+		name:    "safari_16",
+		helloID: utls.HelloSafari_16_0,
+		// Safari不发送ENABLE_PUSH；服务器若推送，Go会以协议错误关闭该连接
+		http2Settings: []http2.Setting{
+			{ID: http2.SettingInitialWindowSize, Val: 4194304},
+			{ID: http2.SettingMaxConcurrentStreams, Val: 100},
+		},
+		connectionWindow:  10485760,
+		pseudoHeaderOrder: "m,s,p,a",
+		expectedJA4:       "t13d2014h2_a09f3c656075_14788d8d241b",
+	},
+	"edge_85": {
+		name:    "edge_85",
+		helloID: utls.HelloEdge_85,
+		http2Settings: []http2.Setting{
+			{ID: http2.SettingHeaderTableSize, Val: 65536},
+			{ID: http2.SettingMaxConcurrentStreams, Val: 1000},
+			{ID: http2.SettingInitialWindowSize, Val: 6291456},
+			{ID: http2.SettingMaxHeaderListSize, Val: 262144},
+		},
+		connectionWindow:  15663105,
+		pseudoHeaderOrder: "m,a,s,p",
+		expectedJA4:       "t13d1515h2_8daaf6152771_de4a06bb82e3",
+	},
+}
+
+// lookupTLSProfile 按名称查找指纹配置
+func lookupTLSProfile(name string) (*tlsProfile, error) {
+	p, ok := tlsProfiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("TLS指纹配置无效: %s", name)
+	}
+	return p, nil
+}
+
+// applyHTTP2 将影响本端流控与解码的SETTINGS取值写入传输层的HTTP/2配置
+// 使Go内部状态与http2Conn改写后发出的取值一致；
+// MAX_HEADER_LIST_SIZE只在SETTINGS中通告，不改变传输层（含HTTP/1.1）的响应头上限
+func (p *tlsProfile) applyHTTP2(transport *http.Transport) {
+	cfg := &http.HTTP2Config{MaxReceiveBufferPerConnection: int(p.connectionWindow)}
+	for _, s := range p.http2Settings {
+		switch s.ID {
+		case http2.SettingHeaderTableSize:
+			cfg.MaxDecoderHeaderTableSize = int(s.Val)
+		case http2.SettingInitialWindowSize:
+			cfg.MaxReceiveBufferPerStream = int(s.Val)
+		case http2.SettingMaxFrameSize:
+			cfg.MaxReadFrameSize = int(s.Val)
+		}
+	}
+	transport.HTTP2 = cfg
+}
+
+// spec 生成一份ClientHello规格
+// Chrome预设每次生成都会重新打乱扩展顺序，与真实浏览器一致
+// 参数 http1Only: 为true时ALPN只保留http/1.1，配合强制HTTP/1.1的协议选项
+func (p *tlsProfile) spec(http1Only bool) (*utls.ClientHelloSpec, error) {
+	spec, err := utls.UTLSIdToSpec(p.helloID)
+	if err != nil {
+		return nil, fmt.Errorf("TLS指纹配置无效: %v", err)
+	}
+	if http1Only {
+		for _, ext := range spec.Extensions {
+			if alpn, ok := ext.(*utls.ALPNExtension); ok {
+				alpn.AlpnProtocols = []string{"http/1.1"}
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// handshake 在已建立的连接上按指纹完成TLS握手
+func (p *tlsProfile) handshake(ctx context.Context, raw net.Conn, serverName string, http1Only bool) (net.Conn, error) {
+	spec, err := p.spec(http1Only)
+	if err != nil {
+		return nil, err
+	}
+	uconn := utls.UClient(raw, &utls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true, // 忽略证书验证，与默认传输层保持一致
+	}, utls.HelloCustom)
+	if err := uconn.ApplyPreset(spec); err != nil {
+		return nil, fmt.Errorf("TLS指纹配置无效: %v", err)
+	}
+	if err := uconn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	conn := &utlsConn{UConn: uconn}
+	if uconn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+		return newHTTP2Conn(conn, p), nil
+	}
+	return conn, nil
+}
+
+// utlsConn 包装uTLS连接
+// 提供crypto/tls类型的ConnectionState，使net/http能识别ALPN并启用HTTP/2
+type utlsConn struct {
+	*utls.UConn
+}
+
+func (c *utlsConn) ConnectionState() tls.ConnectionState {
+	cs := c.UConn.ConnectionState()
+	return tls.ConnectionState{
+		Version:            cs.Version,
+		HandshakeComplete:  cs.HandshakeComplete,
+		DidResume:          cs.DidResume,
+		CipherSuite:        cs.CipherSuite,
+		NegotiatedProtocol: cs.NegotiatedProtocol,
+		ServerName:         cs.ServerName,
+		PeerCertificates:   cs.PeerCertificates,
+		VerifiedChains:     cs.VerifiedChains,
+	}
+}
+
+// TLSFingerprint 本地生成指定配置的ClientHello并计算JA3/JA4指纹
+// 不发起网络连接，可用于校验指纹配置是否符合预期
+// 参数 profile: 配置名称，如chrome_133、firefox_120、safari_16、edge_85
+// 返回值: {profile, ja3, ja3_hash, ja4, expected_ja4, match, http2}
+func TLSFingerprint(profile string) (map[string]interface{}, error) {
+	p, err := lookupTLSProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return p.fingerprint()
+}
+
+// fingerprint 生成ClientHello并计算指纹
+func (p *tlsProfile) fingerprint() (map[string]interface{}, error) {
+	spec, err := p.spec(false)
+	if err != nil {
+		return nil, err
+	}
+	uconn := utls.UClient(nil, &utls.Config{ServerName: "example.com"}, utls.HelloCustom)
+	if err := uconn.ApplyPreset(spec); err != nil {
+		return nil, fmt.Errorf("TLS指纹配置无效: %v", err)
+	}
+	if err := uconn.BuildHandshakeState(); err != nil {
+		return nil, fmt.Errorf("TLS指纹配置无效: %v", err)
+	}
+	hello, err := parseClientHello(uconn.HandshakeState.Hello.Raw)
+	if err != nil {
+		return nil, err
+	}
+	ja3 := hello.ja3()
+	ja3Hash := md5.Sum([]byte(ja3))
+	ja4 := hello.ja4()
+	return map[string]interface{}{
+		"profile":      p.name,
+		"ja3":          ja3,
+		"ja3_hash":     hex.EncodeToString(ja3Hash[:]),
+		"ja4":          ja4,
+		"expected_ja4": p.expectedJA4,
+		"match":        p.expectedJA4 == ja4,
+		"http2":        p.http2Fingerprint(),
+	}, nil
+}
+
+// http2Fingerprint 按Akamai格式描述发出的HTTP/2参数：SETTINGS|WINDOW_UPDATE|PRIORITY|伪头部顺序
+func (p *tlsProfile) http2Fingerprint() string {
+	settings := make([]string, 0, len(p.http2Settings))
+	for _, s := range p.http2Settings {
+		settings = append(settings, fmt.Sprintf("%d:%d", s.ID, s.Val))
+	}
+	return fmt.Sprintf("%s|%d|0|%s", strings.Join(settings, ";"), p.connectionWindow, p.pseudoHeaderOrder)
+}
+
+// clientHello ClientHello中参与指纹计算的字段
+type clientHello struct {
+	version           uint16
+	ciphers           []uint16
+	extensions        []uint16
+	curves            []uint16
+	pointFormats      []uint8
+	signatureAlgs     []uint16
+	supportedVersions []uint16
+	alpn              []string
+	hasSNI            bool
+}
+
+// parseClientHello 解析握手消息（不含记录层头部）
+func parseClientHello(raw []byte) (*clientHello, error) {
+	errMalformed := fmt.Errorf("TLS指纹解析失败: ClientHello格式错误")
+	s := cryptobyte.String(raw)
+	var msgType uint8
+	var body, random, sessionID, cipherBytes, compression, exts cryptobyte.String
+	h := &clientHello{}
+	if !s.ReadUint8(&msgType) || msgType != 1 || !s.ReadUint24LengthPrefixed(&body) ||
+		!body.ReadUint16(&h.version) || !body.ReadBytes((*[]byte)(&random), 32) ||
+		!body.ReadUint8LengthPrefixed(&sessionID) || !body.ReadUint16LengthPrefixed(&cipherBytes) ||
+		!body.ReadUint8LengthPrefixed(&compression) || !body.ReadUint16LengthPrefixed(&exts) {
+		return nil, errMalformed
+	}
+	for !cipherBytes.Empty() {
+		var c uint16
+		if !cipherBytes.ReadUint16(&c) {
+			return nil, errMalformed
+		}
+		h.ciphers = append(h.ciphers, c)
+	}
+	for !exts.Empty() {
+		var typ uint16
+		var data cryptobyte.String
+		if !exts.ReadUint16(&typ) || !exts.ReadUint16LengthPrefixed(&data) {
+			return nil, errMalformed
+		}
+		h.extensions = append(h.extensions, typ)
+		var list cryptobyte.String
+		switch typ {
+		case 0x0000: // server_name
+			h.hasSNI = true
+		case 0x000a: // supported_groups
+			if data.ReadUint16LengthPrefixed(&list) {
+				h.curves = readUint16s(list)
+			}
+		case 0x000b: // ec_point_formats
+			if data.ReadUint8LengthPrefixed(&list) {
+				h.pointFormats = []uint8(list)
+			}
+		case 0x000d: // signature_algorithms
+			if data.ReadUint16LengthPrefixed(&list) {
+				h.signatureAlgs = readUint16s(list)
+			}
+		case 0x0010: // application_layer_protocol_negotiation
+			if data.ReadUint16LengthPrefixed(&list) {
+				for !list.Empty() {
+					var proto cryptobyte.String
+					if !list.ReadUint8LengthPrefixed(&proto) {
+						break
+					}
+					h.alpn = append(h.alpn, string(proto))
+				}
+			}
+		case 0x002b: // supported_versions
+			if data.ReadUint8LengthPrefixed(&list) {
+				h.supportedVersions = readUint16s(list)
+			}
+		}
+	}
+	return h, nil
+}
+
+// readUint16s 读取连续的uint16列表
+func readUint16s(s cryptobyte.String) []uint16 {
+	var out []uint16
+	var v uint16
+	for s.ReadUint16(&v) {
+		out = append(out, v)
+	}
+	return out
+}
+
+// isGREASE 判断是否为GREASE占位值（0x?a?a）
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// withoutGREASE 过滤GREASE值
+func withoutGREASE(values []uint16) []uint16 {
+	var out []uint16
+	for _, v := range values {
+		if !isGREASE(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ja3 计算JA3字符串：版本,密码套件,扩展,曲线,点格式
+func (h *clientHello) ja3() string {
+	join := func(values []uint16) string {
+		parts := make([]string, 0, len(values))
+		for _, v := range withoutGREASE(values) {
+			parts = append(parts, strconv.Itoa(int(v)))
+		}
+		return strings.Join(parts, "-")
+	}
+	formats := make([]string, 0, len(h.pointFormats))
+	for _, f := range h.pointFormats {
+		formats = append(formats, strconv.Itoa(int(f)))
+	}
+	return fmt.Sprintf("%d,%s,%s,%s,%s", h.version,
+		join(h.ciphers), join(h.extensions), join(h.curves), strings.Join(formats, "-"))
+}
+
+// ja4 计算JA4指纹（TCP上的TLS）
+func (h *clientHello) ja4() string {
+	version := h.version
+	for _, v := range withoutGREASE(h.supportedVersions) {
+		if v > version {
+			version = v
+		}
+	}
+	versionCode := map[uint16]string{
+		tls.VersionTLS10: "10", tls.VersionTLS11: "11",
+		tls.VersionTLS12: "12", tls.VersionTLS13: "13",
+	}[version]
+	if versionCode == "" {
+		versionCode = "00"
+	}
+	sni := "i"
+	if h.hasSNI {
+		sni = "d"
+	}
+	alpn := "00"
+	if len(h.alpn) > 0 && len(h.alpn[0]) > 0 {
+		first := h.alpn[0]
+		alpn = first[:1] + first[len(first)-1:]
+	}
+	ciphers := withoutGREASE(h.ciphers)
+	extensions := withoutGREASE(h.extensions)
+	a := fmt.Sprintf("t%s%s%02d%02d%s", versionCode, sni,
+		min(len(ciphers), 99), min(len(extensions), 99), alpn)
+
+	hexList := func(values []uint16, sorted bool) string {
+		values = append([]uint16(nil), values...)
+		if sorted {
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		}
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			parts = append(parts, fmt.Sprintf("%04x", v))
+		}
+		return strings.Join(parts, ",")
+	}
+	truncatedHash := func(s string) string {
+		if s == "" {
+			return "000000000000"
+		}
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])[:12]
+	}
+	// 扩展部分排除SNI与ALPN
+	var hashedExtensions []uint16
+	for _, e := range extensions {
+		if e != 0x0000 && e != 0x0010 {
+			hashedExtensions = append(hashedExtensions, e)
+		}
+	}
+	c := hexList(hashedExtensions, true)
+	if sigs := withoutGREASE(h.signatureAlgs); len(sigs) > 0 {
+		c += "_" + hexList(sigs, false)
+	}
+	return fmt.Sprintf("%s_%s_%s", a, truncatedHash(hexList(ciphers, true)), truncatedHash(c))
+}
@@ -0,0 +1,278 @@
+package gonethttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// browserFingerprints 各浏览器版本公开的指纹，与配置独立维护
+// Chrome自110起每次连接随机打乱扩展顺序，JA3哈希不固定，比较扩展排序后的JA3字符串；
+// HTTP/2按Akamai格式比较SETTINGS、WINDOW_UPDATE与伪头部顺序（PRIORITY帧不模拟）
+var browserFingerprints = map[string]struct {
+	ja3Hash   string // 为空时比较ja3Sorted
+	ja3Sorted string
+	ja4       string
+	http2     string
+}{
+	"chrome_133": {
+		ja3Sorted: "771,4865-4866-4867-49195-49199-49196-49200-52393-52392-49171-49172-156-157-47-53,0-5-10-11-13-16-18-23-27-35-43-45-51-17613-65037-65281,4588-29-23-24,0",
+		ja4:       "t13d1516h2_8daaf6152771_d8a2da3f94cd",
+		http2:     "1:65536;2:0;4:6291456;6:262144|15663105|m,a,s,p",
+	},
+	"firefox_120": {
+		ja3Hash: "b5001237acdf006056b409cc433726b0",
+		ja4:     "t13d1715h2_5b57614c22b0_5c2c66f702b0",
+		http2:   "1:65536;2:0;4:131072;5:16384|12517377|m,p,a,s",
+	},
+	"safari_16": {
+		ja3Hash: "773906b0efdefa24a7f2b8eb6985bf37",
+		ja4:     "t13d2014h2_a09f3c656075_14788d8d241b",
+		http2:   "4:4194304;3:100|10485760|m,s,p,a",
+	},
+	"edge_85": {
+		ja3Hash: "b32309a26951912be7dba376398abc3b",
+		ja4:     "t13d1515h2_8daaf6152771_de4a06bb82e3",
+		http2:   "1:65536;3:1000;4:6291456;6:262144|15663105|m,a,s,p",
+	},
+}
+
+// captureClientHello 在本地监听端口上读取客户端发出的第一条TLS记录（ClientHello）
+func captureClientHello(t *testing.T) (addr string, hello <-chan []byte) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(ch)
+			return
+		}
+		defer conn.Close()
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(conn, header); err != nil || header[0] != 22 {
+			close(ch)
+			return
+		}
+		record := make([]byte, binary.BigEndian.Uint16(header[3:5]))
+		if _, err := io.ReadFull(conn, record); err != nil {
+			close(ch)
+			return
+		}
+		ch <- record
+	}()
+	return ln.Addr().String(), ch
+}
+
+// sortedJA3 将JA3字符串中的扩展按数值排序
+func sortedJA3(ja3 string) string {
+	fields := strings.Split(ja3, ",")
+	exts := strings.Split(fields[2], "-")
+	slices.SortFunc(exts, func(a, b string) int {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+	fields[2] = strings.Join(exts, "-")
+	return strings.Join(fields, ",")
+}
+
+func TestClientHelloMatchesBrowser(t *testing.T) {
+	for name, want := range browserFingerprints {
+		t.Run(name, func(t *testing.T) {
+			addr, helloCh := captureClientHello(t)
+			_, port, _ := net.SplitHostPort(addr)
+			// 监听端读完ClientHello即断开，请求本身必然失败
+			// 使用主机名访问：目标为IP地址时与浏览器一样不发送SNI，指纹随之不同
+			Do(context.Background(), &Request{
+				Method:     "GET",
+				URL:        "https://localhost:" + port + "/",
+				Headers:    map[string]string{"User-Agent": "test"},
+				TLSProfile: name,
+			})
+			raw, ok := <-helloCh
+			if !ok {
+				t.Fatal("no ClientHello captured")
+			}
+			hello, err := parseClientHello(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ja3 := hello.ja3()
+			if want.ja3Hash != "" {
+				sum := md5.Sum([]byte(ja3))
+				if got := hex.EncodeToString(sum[:]); got != want.ja3Hash {
+					t.Errorf("JA3 = %s (%s), want %s", got, ja3, want.ja3Hash)
+				}
+			} else if got := sortedJA3(ja3); got != want.ja3Sorted {
+				t.Errorf("JA3 (sorted extensions) = %s, want %s", got, want.ja3Sorted)
+			}
+			if got := hello.ja4(); got != want.ja4 {
+				t.Errorf("JA4 = %s, want %s", got, want.ja4)
+			}
+		})
+	}
+}
+
+// http2Capture 记录HTTP/2连接上客户端发出的SETTINGS、连接级WINDOW_UPDATE与各请求的伪头部顺序
+type http2Capture struct {
+	settings []string
+	window   uint32
+	pseudo   []string            // 每个请求的伪头部顺序
+	headers  []map[string]string // 每个请求的普通头部
+}
+
+func (c *http2Capture) fingerprint(i int) string {
+	return fmt.Sprintf("%s|%d|%s", strings.Join(c.settings, ";"), c.window, c.pseudo[i])
+}
+
+// serveHTTP2 在TLS监听上接受一个h2连接，对每个请求返回204，直到客户端断开
+func serveHTTP2(conn net.Conn, capture *http2Capture) error {
+	preface := make([]byte, len(http2.ClientPreface))
+	if _, err := io.ReadFull(conn, preface); err != nil {
+		return err
+	}
+	fr := http2.NewFramer(conn, conn)
+	fr.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	if err := fr.WriteSettings(); err != nil {
+		return err
+	}
+	var hbuf strings.Builder
+	enc := hpack.NewEncoder(&hbuf)
+	letters := map[string]string{":method": "m", ":authority": "a", ":scheme": "s", ":path": "p"}
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			return nil
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				continue
+			}
+			if capture.settings == nil {
+				f.ForeachSetting(func(s http2.Setting) error {
+					capture.settings = append(capture.settings, fmt.Sprintf("%d:%d", s.ID, s.Val))
+					return nil
+				})
+			}
+			fr.WriteSettingsAck()
+		case *http2.WindowUpdateFrame:
+			if f.StreamID == 0 && capture.window == 0 {
+				capture.window = f.Increment
+			}
+		case *http2.MetaHeadersFrame:
+			var order []string
+			headers := map[string]string{}
+			for _, hf := range f.Fields {
+				if hf.IsPseudo() {
+					order = append(order, letters[hf.Name])
+				} else {
+					headers[hf.Name] = hf.Value
+				}
+			}
+			capture.pseudo = append(capture.pseudo, strings.Join(order, ","))
+			capture.headers = append(capture.headers, headers)
+			hbuf.Reset()
+			enc.WriteField(hpack.HeaderField{Name: ":status", Value: "204"})
+			fr.WriteHeaders(http2.HeadersFrameParam{
+				StreamID:      f.StreamID,
+				BlockFragment: []byte(hbuf.String()),
+				EndStream:     true,
+				EndHeaders:    true,
+			})
+		}
+	}
+}
+
+func TestHTTP2FingerprintMatchesBrowser(t *testing.T) {
+	// 借用httptest的自签名证书
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	certs := ts.TLS.Certificates
+	ts.Close()
+
+	for name, want := range browserFingerprints {
+		t.Run(name, func(t *testing.T) {
+			ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs, NextProtos: []string{"h2"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			capture := &http2Capture{}
+			done := make(chan error, 1)
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					done <- err
+					return
+				}
+				defer conn.Close()
+				done <- serveHTTP2(conn, capture)
+			}()
+
+			c, err := NewClient(ClientConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 同一连接上发送两个请求，第二个请求的头部块依赖Go编码器的动态表
+			for i := 0; i < 2; i++ {
+				res, err := c.Do(context.Background(), &Request{
+					Method:     "GET",
+					URL:        fmt.Sprintf("https://%s/%d", ln.Addr(), i),
+					Headers:    map[string]string{"User-Agent": "test", "X-Seq": strconv.Itoa(i)},
+					TLSProfile: name,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.StatusCode != http.StatusNoContent || res.Protocol != "HTTP/2.0" {
+					t.Fatalf("got %d %s, want 204 over HTTP/2.0", res.StatusCode, res.Protocol)
+				}
+			}
+			c.Close()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range capture.pseudo {
+				if got := capture.fingerprint(i); got != want.http2 {
+					t.Errorf("request %d: HTTP/2 fingerprint = %s, want %s", i, got, want.http2)
+				}
+				if capture.headers[i]["user-agent"] != "test" || capture.headers[i]["x-seq"] != strconv.Itoa(i) {
+					t.Errorf("request %d: headers = %v", i, capture.headers[i])
+				}
+			}
+			if len(capture.pseudo) != 2 {
+				t.Fatalf("server saw %d requests, want 2", len(capture.pseudo))
+			}
+		})
+	}
+}
+
+func TestHTTP2SettingsDoNotChangeHTTP1HeaderLimit(t *testing.T) {
+	for name, p := range tlsProfiles {
+		transport := &http.Transport{}
+		p.applyHTTP2(transport)
+		if transport.MaxResponseHeaderBytes != 0 {
+			t.Errorf("%s: MaxResponseHeaderBytes = %d, want default", name, transport.MaxResponseHeaderBytes)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// transport.go
+package gonethttp
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// 协议选项
+const (
+	protocolHTTP1    = "http1"     // 强制HTTP/1.1
+	protocolPreferH2 = "prefer_h2" // TLS下通过ALPN优先协商HTTP/2，失败回退HTTP/1.1（默认）
+	protocolH2C      = "h2c"       // 明文HTTP/2（prior knowledge），仅适用于http://地址
+)
+
+// transportOptions 决定传输层行为的参数，同时作为共享Transport的缓存键
+type transportOptions struct {
+	Client       int64  // 客户端ID，客户端有独立的拨号配置，不与其他客户端共享连接
+	Proxy        string // 代理地址
+	Protocol     string // 协议选项
+	TLSProfile   string // 浏览器TLS指纹配置名称，空表示使用Go默认ClientHello
+	LocalAddress string // 请求级本地出口地址（IP或网卡名称）
+	Policy       uint64 // 全局目标策略版本，拨号函数按该版本的策略检查目标
+}
+
+// maxCachedTransports 缓存的传输层数量上限
+// 轮换代理或出口地址时每组参数都会新建传输层，超过上限时淘汰最久未使用的并关闭其空闲连接
+const maxCachedTransports = 64
+
+// cachedTransport 传输层缓存条目
+type cachedTransport struct {
+	opts      transportOptions
+	transport *http.Transport
+}
+
+var (
+	transportMu    sync.Mutex
+	transportCache = map[transportOptions]*list.Element{} // 按参数缓存的传输层
+	transportOrder = list.New()                           // 按最近使用排序，队首最新
+)
+
+// dialFunc 建立TCP连接的函数，签名与http.Transport.DialContext一致
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// transportFor 获取指定参数对应的共享传输层
+// 参数相同的请求复用同一个Transport，以便并发请求共享连接池
+// 代理配置处理（方案优先级）
+//  1. 当提供有效代理地址时：创建带代理的自定义Transport
+//  2. 无代理时：克隆默认Transport保证线程安全
+//
+// 两种情况都显式设置Protocols，保证有无代理时协商的协议一致
+//
+// 参数 dial: 建立TCP连接的函数（直连目标或连接代理服务器时使用）
+func transportFor(opts transportOptions, dial dialFunc) (*http.Transport, error) {
+	opts.Protocol = strings.ToLower(opts.Protocol)
+	if opts.Protocol == "" {
+		opts.Protocol = protocolPreferH2
+	}
+	protocols, err := parseProtocols(opts.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if elem, ok := transportCache[opts]; ok {
+		transportOrder.MoveToFront(elem)
+		return elem.Value.(*cachedTransport).transport, nil
+	}
+	var transport *http.Transport
+	if opts.Proxy != "" {
+		// 解析代理地址
+		proxyURL, errProxy := url.Parse(opts.Proxy)
+		if errProxy != nil {
+			return nil, fmt.Errorf("代理地址解析失败: %v", errProxy)
+		}
+		// 创建带代理的传输层
+		transport = &http.Transport{
+			Proxy: http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // 忽略证书验证
+			},
+			// 与默认传输层一致，被淘汰的传输层上仍在使用的连接归还后也会超时关闭
+			IdleConnTimeout: 90 * time.Second,
+		}
+	} else {
+		// 使用默认传输层并克隆配置
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true, // 忽略证书验证
+		}
+	}
+	transport.Protocols = protocols
+	transport.DialContext = dial
+	if opts.TLSProfile != "" {
+		profile, errProfile := lookupTLSProfile(opts.TLSProfile)
+		if errProfile != nil {
+			return nil, errProfile
+		}
+		applyTLSProfile(transport, profile, opts, dial)
+	}
+	transportCache[opts] = transportOrder.PushFront(&cachedTransport{opts: opts, transport: transport})
+	for transportOrder.Len() > maxCachedTransports {
+		removeTransport(transportOrder.Back())
+	}
+	return transport, nil
+}
+
+// removeTransport 移除缓存条目并关闭其空闲连接，调用方需持有transportMu
+// 正在进行的请求不受影响
+func removeTransport(elem *list.Element) {
+	entry := transportOrder.Remove(elem).(*cachedTransport)
+	delete(transportCache, entry.opts)
+	entry.transport.CloseIdleConnections()
+}
+
+// parseProtocols 将协议选项转换为http.Protocols
+func parseProtocols(protocol string) (*http.Protocols, error) {
+	protocols := new(http.Protocols)
+	switch protocol {
+	case protocolHTTP1:
+		protocols.SetHTTP1(true)
+	case protocolPreferH2:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case protocolH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil, fmt.Errorf("协议选项无效: %s", protocol)
+	}
+	return protocols, nil
+}
+
+// negotiatedProtocol 返回TLS握手协商出的ALPN协议
+func negotiatedProtocol(res *http.Response) string {
+	if res.TLS == nil {
+		return ""
+	}
+	return res.TLS.NegotiatedProtocol
+}
+
+// applyTLSProfile 让传输层使用指定浏览器指纹建立TLS连接
+// https请求由DialTLSContext自行完成代理隧道与uTLS握手，
+// 因为设置了Proxy时net/http会绕过DialTLSContext改用crypto/tls；
+// http请求仍交给net/http按原代理配置转发
+func applyTLSProfile(transport *http.Transport, profile *tlsProfile, opts transportOptions, dial dialFunc) {
+	proxyFunc := transport.Proxy
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if req.URL.Scheme == "https" || proxyFunc == nil {
+			return nil, nil
+		}
+		return proxyFunc(req)
+	}
+	http1Only := opts.Protocol == protocolHTTP1
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		raw, err := dialVia(ctx, dial, opts.Proxy, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err := profile.handshake(ctx, raw, host, http1Only)
+		if err != nil {
+			raw.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+	profile.applyHTTP2(transport)
+}
+
+// baseDialer 建立TCP连接的默认拨号器，参数与http.DefaultTransport一致
+var baseDialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+}
+
+// closeTransports 关闭并移除客户端专属的传输层
+func closeTransports(clientID int64) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	for opts, elem := range transportCache {
+		if opts.Client == clientID {
+			removeTransport(elem)
+		}
+	}
+}
+
+// resetTransports 关闭并移除全部缓存的传输层
+func resetTransports() {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	for _, elem := range transportCache {
+		removeTransport(elem)
+	}
+}
+
+// dialVia 建立到目标地址的TCP连接，配置了代理时经代理建立隧道
+// 支持的代理协议：http/https（CONNECT）、socks5/socks5h
+func dialVia(ctx context.Context, dial dialFunc, proxyAddr, network, addr string) (net.Conn, error) {
+	if proxyAddr == "" {
+		return dial(ctx, network, addr)
+	}
+	proxyURL, err := url.Parse(proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址解析失败: %v", err)
+	}
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		dialer, errSocks := proxy.FromURL(proxyURL, forwardDialer(dial))
+		if errSocks != nil {
+			return nil, fmt.Errorf("代理地址解析失败: %v", errSocks)
+		}
+		return dialer.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	case "http", "https":
+		return dialConnect(ctx, dial, proxyURL, network, addr)
+	default:
+		return nil, fmt.Errorf("代理地址解析失败: 不支持的代理协议 %s", proxyURL.Scheme)
+	}
+}
+
+// forwardDialer 让dialFunc满足proxy.Dialer与proxy.ContextDialer接口
+type forwardDialer dialFunc
+
+func (d forwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+func (d forwardDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
+
+// dialConnect 通过HTTP CONNECT建立隧道
+func dialConnect(ctx context.Context, dial dialFunc, proxyURL *url.URL, network, addr string) (net.Conn, error) {
+	proxyHost := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyHost = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := dial(ctx, network, proxyHost)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         proxyURL.Hostname(),
+			InsecureSkipVerify: true, // 忽略证书验证
+		})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	// CONNECT期间遵守请求期限
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// CONNECT成功后连接即为隧道，不能关闭或读取响应体
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxyconnect tcp: 代理CONNECT失败: %s", res.Status)
+	}
+	if br.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("proxyconnect tcp: 代理在CONNECT响应后返回了多余数据")
+	}
+	return conn, nil
+}
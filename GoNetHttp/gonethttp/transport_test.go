@@ -0,0 +1,136 @@
+package gonethttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func protoHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, r.Proto)
+}
+
+func TestProtocolOptions(t *testing.T) {
+	tlsSrv := httptest.NewUnstartedServer(http.HandlerFunc(protoHandler))
+	tlsSrv.EnableHTTP2 = true
+	tlsSrv.StartTLS()
+	defer tlsSrv.Close()
+	h2cSrv := httptest.NewUnstartedServer(http.HandlerFunc(protoHandler))
+	h2cSrv.Config.Protocols = new(http.Protocols)
+	h2cSrv.Config.Protocols.SetHTTP1(true)
+	h2cSrv.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2cSrv.Start()
+	defer h2cSrv.Close()
+
+	for _, tc := range []struct {
+		url, protocol, want, alpn string
+	}{
+		{tlsSrv.URL, "", "HTTP/2.0", "h2"},
+		{tlsSrv.URL, "prefer_h2", "HTTP/2.0", "h2"},
+		{tlsSrv.URL, "HTTP1", "HTTP/1.1", ""}, // 只用HTTP/1.1时不发送ALPN
+		{h2cSrv.URL, "h2c", "HTTP/2.0", ""},
+		{h2cSrv.URL, "", "HTTP/1.1", ""},
+	} {
+		res, err := Do(context.Background(), &Request{
+			Method:   "GET",
+			URL:      tc.url,
+			Headers:  map[string]string{"User-Agent": "test"},
+			Protocol: tc.protocol,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text != tc.want || res.Protocol != tc.want || res.ALPN != tc.alpn {
+			t.Errorf("%s %q: server saw %s, response %s alpn %q; want %s alpn %q",
+				tc.url, tc.protocol, res.Text, res.Protocol, res.ALPN, tc.want, tc.alpn)
+		}
+	}
+
+	_, err := Do(context.Background(), &Request{Method: "GET", URL: tlsSrv.URL, Headers: map[string]string{"User-Agent": "test"}, Protocol: "spdy"})
+	if code := ErrorCode(err); code != ErrProtocolConfig {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrProtocolConfig)
+	}
+}
+
+func TestHTTPProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 正向代理收到的是绝对URL
+		fmt.Fprintf(w, "proxied %s %v", r.URL, r.URL.IsAbs())
+	}))
+	defer proxy.Close()
+
+	res, err := Do(context.Background(), &Request{
+		Method:  "GET",
+		URL:     "http://upstream.test/path",
+		Headers: map[string]string{"User-Agent": "test"},
+		Proxy:   proxy.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "proxied http://upstream.test/path true" || res.RemoteAddr != proxy.Listener.Addr().String() {
+		t.Fatalf("got %q via %s", res.Text, res.RemoteAddr)
+	}
+}
+
+func TestTransportCacheBounded(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(protoHandler))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+	resetTransports()
+	defer resetTransports()
+
+	proxyOpts := func(i int) transportOptions {
+		return transportOptions{Proxy: "http://127.0.0.1:" + strconv.Itoa(10000+i)}
+	}
+	oldest, err := transportFor(transportOptions{}, baseDialer.DialContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 在最旧的传输层上留下一个空闲连接
+	res, err := (&http.Client{Transport: oldest}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	for i := range maxCachedTransports - 1 {
+		if _, err := transportFor(proxyOpts(i), baseDialer.DialContext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 再次使用后第二旧的条目成为最久未使用
+	if again, _ := transportFor(transportOptions{}, baseDialer.DialContext); again != oldest {
+		t.Fatal("cached transport not reused")
+	}
+	transportFor(proxyOpts(maxCachedTransports), baseDialer.DialContext)
+	if _, ok := transportCache[proxyOpts(0)]; ok || len(transportCache) != maxCachedTransports {
+		t.Fatalf("cache size %d, least recently used entry present = %v", len(transportCache), ok)
+	}
+	if _, ok := transportCache[transportOptions{Protocol: protocolPreferH2}]; !ok {
+		t.Fatal("recently used entry evicted")
+	}
+
+	// 填满缓存，最旧的传输层被淘汰时关闭其空闲连接
+	for i := range maxCachedTransports {
+		transportFor(proxyOpts(2000+i), baseDialer.DialContext)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle connection of the evicted transport not closed")
+	}
+}
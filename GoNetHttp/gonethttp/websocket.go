@@ -0,0 +1,287 @@
+// websocket.go
+package gonethttp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultWebSocketReadLimit 未指定max_message_bytes时单条消息的上限
+const defaultWebSocketReadLimit = 16 * 1024 * 1024
+
+// WebSocketFrame 收到的一帧消息或控制帧
+type WebSocketFrame struct {
+	Type   string `json:"type"`             // text/binary/ping/pong/close
+	Data   string `json:"data,omitempty"`   // 文本消息内容，或ping/pong的负载
+	Byte   []byte `json:"byte,omitempty"`   // 二进制消息内容（JSON中为base64）
+	Code   int    `json:"code,omitempty"`   // 关闭码（close帧）
+	Reason string `json:"reason,omitempty"` // 关闭原因（close帧）
+}
+
+// WebSocket 一个WebSocket连接
+// 由读取协程独占读取，收到的帧放入frames，写操作由writeMu串行化
+type WebSocket struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	frames    chan WebSocketFrame
+	closeOnce sync.Once
+	closing   chan struct{}  // Close调用后关闭，读取协程不再等待入队
+	done      chan struct{}  // 读取协程结束后关闭
+	closeInfo WebSocketFrame // 连接结束时的关闭信息
+	readErr   error          // 连接因接收错误（如消息超过上限）结束时的错误
+}
+
+// DialWebSocket 建立WebSocket连接
+// url为ws://或wss://，支持headers、cookies、proxy（http/https/socks5）、client、tls_profile、local_address，
+// timeout_ms限制握手时间（默认45秒）；请求头Sec-WebSocket-Protocol用于协商子协议；
+// max_message_bytes限制单条消息大小（默认16MB），超过时以1009关闭连接
+// 返回值中的http.Response为握手响应，其响应体已关闭
+func DialWebSocket(req *Request) (*WebSocket, *http.Response, error) {
+	spec := *req
+	if spec.Method == "" {
+		spec.Method = http.MethodGet
+	}
+	if err := prepareSpec(&spec); err != nil {
+		return nil, nil, err
+	}
+	return dialWebSocket(&spec)
+}
+
+// Subprotocol 协商得到的子协议
+func (ws *WebSocket) Subprotocol() string {
+	return ws.conn.Subprotocol()
+}
+
+// Send 发送一帧
+// 参数 frameType: text（默认）/binary/ping/pong，ping/pong的data为负载
+func (ws *WebSocket) Send(frameType string, data []byte) error {
+	var err error
+	switch frameType {
+	case "", "text":
+		err = ws.write(websocket.TextMessage, data)
+	case "binary":
+		err = ws.write(websocket.BinaryMessage, data)
+	case "ping":
+		err = ws.conn.WriteControl(websocket.PingMessage, data, time.Now().Add(10*time.Second))
+	case "pong":
+		err = ws.conn.WriteControl(websocket.PongMessage, data, time.Now().Add(10*time.Second))
+	default:
+		return fmt.Errorf("请求参数解析失败: 未知的帧类型 %s", frameType)
+	}
+	if err != nil {
+		return fmt.Errorf("WebSocket发送失败: %v", err)
+	}
+	return nil
+}
+
+// Receive 接收下一帧：收到的消息，或ping/pong控制帧（收到ping时已自动回复pong）
+// ctx结束时返回ctx.Err()；连接已关闭且帧已取完时返回close帧与io.EOF，
+// 因消息超过上限而关闭时返回close帧与"WebSocket接收失败"错误
+func (ws *WebSocket) Receive(ctx context.Context) (WebSocketFrame, error) {
+	select {
+	case frame := <-ws.frames:
+		return frame, nil
+	default:
+	}
+	select {
+	case frame := <-ws.frames:
+		return frame, nil
+	case <-ws.done:
+		select {
+		case frame := <-ws.frames:
+			return frame, nil
+		default:
+		}
+		if ws.readErr != nil {
+			return ws.closeInfo, ws.readErr
+		}
+		return ws.closeInfo, io.EOF
+	case <-ctx.Done():
+		return WebSocketFrame{}, ctx.Err()
+	}
+}
+
+// Close 发送关闭帧并断开连接，返回连接结束时的关闭信息
+// 参数 code: 关闭码，0表示1000（正常关闭）；等待服务器回应关闭帧最多2秒
+func (ws *WebSocket) Close(code int, reason string) WebSocketFrame {
+	ws.closeOnce.Do(func() {
+		if code == 0 {
+			code = websocket.CloseNormalClosure
+		}
+		close(ws.closing)
+		msg := websocket.FormatCloseMessage(code, reason)
+		if err := ws.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(2*time.Second)); err == nil {
+			select {
+			case <-ws.done:
+			case <-time.After(2 * time.Second):
+			}
+		}
+		ws.conn.Close()
+	})
+	<-ws.done
+	return ws.closeInfo
+}
+
+// dialWebSocket 按请求描述完成握手
+// 与HTTP请求共用拨号、代理隧道、TLS指纹与目标地址安全策略
+func dialWebSocket(spec *Request) (*WebSocket, *http.Response, error) {
+	target, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("WebSocket连接失败: %v", err)
+	}
+	if target.Scheme != "ws" && target.Scheme != "wss" {
+		return nil, nil, fmt.Errorf("WebSocket连接失败: 不支持的协议 %s", target.Scheme)
+	}
+	if spec.MaxMessageBytes < 0 {
+		return nil, nil, fmt.Errorf("请求参数解析失败: max_message_bytes不能为负数")
+	}
+	c, err := lookupClient(spec.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 安全策略按对应的http/https协议检查
+	parts := requestParts(&http.Request{URL: target})
+	parts.scheme = strings.Replace(parts.scheme, "ws", "http", 1)
+	guard := c.destinationGuard()
+	if err := guard.checkRequest(parts); err != nil {
+		return nil, nil, err
+	}
+	opts := transportOptions{Proxy: spec.Proxy, TLSProfile: spec.TLSProfile, LocalAddress: spec.LocalAddress}
+	dial, err := c.targetDialer(opts, guard)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer := &websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialVia(ctx, dial, spec.Proxy, network, addr)
+		},
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // 忽略证书验证
+		},
+		HandshakeTimeout: 45 * time.Second,
+		Jar:              c.cookieJar(),
+	}
+	if spec.TimeoutMs > 0 {
+		dialer.HandshakeTimeout = time.Duration(spec.TimeoutMs) * time.Millisecond
+	}
+	if spec.TLSProfile != "" {
+		profile, errProfile := lookupTLSProfile(spec.TLSProfile)
+		if errProfile != nil {
+			return nil, nil, errProfile
+		}
+		// WebSocket握手基于HTTP/1.1，ALPN只声明http/1.1
+		dialer.NetDialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			raw, errDial := dialVia(ctx, dial, spec.Proxy, network, addr)
+			if errDial != nil {
+				return nil, errDial
+			}
+			host, _, _ := net.SplitHostPort(addr)
+			conn, errHandshake := profile.handshake(ctx, raw, host, true)
+			if errHandshake != nil {
+				raw.Close()
+				return nil, errHandshake
+			}
+			return conn, nil
+		}
+	}
+	header := http.Header{}
+	for key, value := range spec.Headers {
+		if strings.EqualFold(key, "Sec-WebSocket-Protocol") {
+			for _, protocol := range strings.Split(value, ",") {
+				dialer.Subprotocols = append(dialer.Subprotocols, strings.TrimSpace(protocol))
+			}
+			continue
+		}
+		header.Add(key, value)
+	}
+	for name, value := range spec.Cookies {
+		header.Add("Cookie", (&http.Cookie{Name: name, Value: value}).String())
+	}
+	conn, res, err := dialer.Dial(spec.URL, header)
+	if err != nil {
+		if res != nil {
+			return nil, nil, fmt.Errorf("WebSocket连接失败: %v (%s)", err, res.Status)
+		}
+		return nil, nil, fmt.Errorf("WebSocket连接失败: %v", err)
+	}
+	ws := &WebSocket{
+		conn:    conn,
+		frames:  make(chan WebSocketFrame, 64),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	readLimit := spec.MaxMessageBytes
+	if readLimit == 0 {
+		readLimit = defaultWebSocketReadLimit
+	}
+	// 超过上限时gorilla向服务器发送1009关闭帧并返回ErrReadLimit
+	conn.SetReadLimit(readLimit)
+	conn.SetPingHandler(func(data string) error {
+		ws.push(WebSocketFrame{Type: "ping", Data: data})
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		ws.push(WebSocketFrame{Type: "pong", Data: data})
+		return nil
+	})
+	go ws.readLoop(readLimit)
+	return ws, res, nil
+}
+
+// readLoop 读取协程，控制帧由处理函数在ReadMessage内部处理
+// 参数 readLimit: 单条消息上限，用于错误信息
+func (ws *WebSocket) readLoop(readLimit int64) {
+	defer close(ws.done)
+	for {
+		messageType, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			ws.closeInfo = WebSocketFrame{Type: "close", Code: websocket.CloseAbnormalClosure, Reason: err.Error()}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				ws.closeInfo = WebSocketFrame{Type: "close", Code: closeErr.Code, Reason: closeErr.Text}
+			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				ws.readErr = fmt.Errorf("WebSocket接收失败: 消息超过%d字节上限", readLimit)
+				ws.closeInfo = WebSocketFrame{Type: "close", Code: websocket.CloseMessageTooBig, Reason: ws.readErr.Error()}
+				ws.conn.Close()
+			}
+			return
+		}
+		if messageType == websocket.TextMessage {
+			ws.push(WebSocketFrame{Type: "text", Data: string(data)})
+		} else {
+			ws.push(WebSocketFrame{Type: "binary", Byte: data})
+		}
+	}
+}
+
+// push 放入接收队列，队列满时暂停读取直到调用方取走帧
+func (ws *WebSocket) push(frame WebSocketFrame) {
+	select {
+	case ws.frames <- frame:
+	case <-ws.closing:
+	}
+}
+
+// write 串行写入数据帧
+func (ws *WebSocket) write(messageType int, data []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	return ws.conn.WriteMessage(messageType, data)
+}
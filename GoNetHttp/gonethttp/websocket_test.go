@@ -0,0 +1,67 @@
+package gonethttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketReadLimit(t *testing.T) {
+	serverClose := make(chan int, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte("small"))
+		conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 64)))
+		_, _, err = conn.ReadMessage()
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			serverClose <- closeErr.Code
+		}
+		close(serverClose)
+	}))
+	defer srv.Close()
+
+	ws, _, err := DialWebSocket(&Request{
+		URL:             "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Headers:         map[string]string{"User-Agent": "test"},
+		MaxMessageBytes: 16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close(0, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	frame, err := ws.Receive(ctx)
+	if err != nil || frame.Data != "small" {
+		t.Fatalf("first frame = %+v, %v", frame, err)
+	}
+	frame, err = ws.Receive(ctx)
+	if code := ErrorCode(err); code != ErrWebSocket {
+		t.Fatalf("oversized message: error code = %d (%v), want %d", code, err, ErrWebSocket)
+	}
+	if frame.Type != "close" || frame.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("close frame = %+v, want 1009", frame)
+	}
+	if code := <-serverClose; code != websocket.CloseMessageTooBig {
+		t.Fatalf("server saw close code %d, want 1009", code)
+	}
+}
+
+func TestWebSocketNegativeReadLimit(t *testing.T) {
+	_, _, err := DialWebSocket(&Request{URL: "ws://127.0.0.1:1", Headers: map[string]string{"User-Agent": "test"}, MaxMessageBytes: -1})
+	if code := ErrorCode(err); code != ErrSpecParse {
+		t.Fatalf("error code = %d (%v), want %d", code, err, ErrSpecParse)
+	}
+}
@@ -0,0 +1,79 @@
+# -*- coding: utf-8 -*-
+"""
+goforpython：GoNetHttp共享库的Python封装，用法与requests一致
+
+    import goforpython
+
+    with goforpython.Session() as s:
+        r = s.get("https://example.com", params={"q": "go"}, timeout=10)
+        r.raise_for_status()
+        print(r.status_code, r.headers["Content-Type"], r.text)
+
+共享库按以下顺序查找：包目录、当前目录、系统库路径（设置环境变量GOFORPYTHON_LIBRARY时只使用该路径），
+文件名为lib_requests_go.dll/.so/.dylib。
+"""
+__version__ = "0.1.0"
+
+from ._lib import LIBRARY_ENV, Library, load_library  # noqa: E402
+from .exceptions import (  # noqa: E402
+    BodyWriteError,
+    CassetteMiss,
+    ConfigError,
+    ConnectionError,
+    CookieImportError,
+    DestinationBlocked,
+    DNSError,
+    EventStreamError,
+    HTTPError,
+    IncompatibleLibrary,
+    InvalidMethod,
+    InvalidRequest,
+    LibraryNotFound,
+    MissingUserAgent,
+    ProxyError,
+    QueueTimeout,
+    ReadError,
+    RequestException,
+    Timeout,
+    TokenError,
+    TooManyRedirects,
+    UnknownHandle,
+    WebSocketError,
+)
+from .models import CaseInsensitiveDict, Response  # noqa: E402
+from .sessions import Session, get, post, request  # noqa: E402
+
+__all__ = [
+    "Session",
+    "Response",
+    "CaseInsensitiveDict",
+    "Library",
+    "load_library",
+    "LIBRARY_ENV",
+    "request",
+    "get",
+    "post",
+    "RequestException",
+    "LibraryNotFound",
+    "IncompatibleLibrary",
+    "InvalidRequest",
+    "InvalidMethod",
+    "MissingUserAgent",
+    "ConfigError",
+    "ProxyError",
+    "UnknownHandle",
+    "CassetteMiss",
+    "DestinationBlocked",
+    "CookieImportError",
+    "TooManyRedirects",
+    "ConnectionError",
+    "DNSError",
+    "Timeout",
+    "QueueTimeout",
+    "ReadError",
+    "TokenError",
+    "EventStreamError",
+    "WebSocketError",
+    "BodyWriteError",
+    "HTTPError",
+]
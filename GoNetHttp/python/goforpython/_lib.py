@@ -0,0 +1,209 @@
+# -*- coding: utf-8 -*-
+"""
+共享库的查找、加载与调用
+
+所有导出函数返回的char*都由Go分配，必须以c_void_p接收并在解析后调用FreeCString释放；
+以c_char_p接收会被ctypes自动转换为bytes，原指针随之丢失而无法释放。
+"""
+import ctypes
+import ctypes.util
+import json
+import os
+import platform
+import sys
+import threading
+
+from .exceptions import IncompatibleLibrary, LibraryNotFound, error_from_code
+
+# 要求的最低库版本（主版本号必须相同）
+MIN_LIB_VERSION = (1, 0, 0)
+
+# 环境变量：共享库的完整路径，设置后不再查找其他位置
+LIBRARY_ENV = "GOFORPYTHON_LIBRARY"
+
+# 库文件名（与README中的go build -o参数一致）
+LIBRARY_BASENAME = "lib_requests_go"
+
+_C_CHAR_P = ctypes.c_char_p
+_LL = ctypes.c_longlong
+_INT = ctypes.c_int
+
+# request_callback/progress_callback: void (*)(long long id, char* json)
+REQUEST_CALLBACK = ctypes.CFUNCTYPE(None, _LL, ctypes.c_char_p)
+
+# 导出函数的参数类型，返回值统一为c_void_p
+_SIGNATURES = {
+    "PostUrlWithProxy": [_C_CHAR_P] * 6,
+    "PostUrlWithProxyV1": [_C_CHAR_P] * 4,
+    "PostUrlWithProxyV2": [_C_CHAR_P] * 5,
+    "DoRequest": [_C_CHAR_P],
+    "BatchRequest": [_C_CHAR_P, _INT],
+    "SubmitRequest": [_C_CHAR_P, REQUEST_CALLBACK],
+    "SubmitRequestWithProgress": [_C_CHAR_P, REQUEST_CALLBACK, REQUEST_CALLBACK],
+    "RequestProgress": [_LL],
+    "PollRequest": [_LL],
+    "WaitRequest": [_LL, _LL],
+    "NewClient": [_C_CHAR_P],
+    "CloseClient": [_LL],
+    "ImportCookies": [_LL, _C_CHAR_P, _C_CHAR_P],
+    "SetCassette": [_C_CHAR_P],
+    "SetDestinationPolicy": [_C_CHAR_P],
+    "TLSFingerprint": [_C_CHAR_P],
+    "OpenResponse": [_C_CHAR_P],
+    "ReadBody": [_LL, _INT],
+    "CloseResponse": [_LL],
+    "OpenBodyWriter": [_LL],
+    "WriteBody": [_LL, ctypes.c_void_p, _INT],
+    "CloseBodyWriter": [_LL, _INT],
+    "OpenEventStream": [_C_CHAR_P],
+    "NextEvent": [_LL, _INT],
+    "CloseEventStream": [_LL],
+    "OpenWebSocket": [_C_CHAR_P],
+    "WebSocketSend": [_LL, _C_CHAR_P],
+    "WebSocketReceive": [_LL, _INT],
+    "CloseWebSocket": [_LL, _INT, _C_CHAR_P],
+    "LibVersion": [],
+    "Capabilities": [],
+}
+
+
+def library_filename():
+    """当前平台的库文件名"""
+    system = platform.system()
+    if system == "Windows":
+        return LIBRARY_BASENAME + ".dll"
+    if system == "Darwin":
+        return LIBRARY_BASENAME + ".dylib"
+    return LIBRARY_BASENAME + ".so"
+
+
+def candidate_paths():
+    """按优先级返回候选路径：包目录、当前目录、系统库路径；设置了环境变量时只使用环境变量"""
+    env = os.environ.get(LIBRARY_ENV)
+    if env:
+        return [env]
+    paths = []
+    name = library_filename()
+    paths.append(os.path.join(os.path.dirname(os.path.abspath(__file__)), name))
+    paths.append(os.path.join(os.getcwd(), name))
+    found = ctypes.util.find_library(LIBRARY_BASENAME.removeprefix("lib"))
+    if found:
+        paths.append(found)
+    return paths
+
+
+class Library:
+    """已加载的共享库，call返回解析后的result字段，失败时抛出对应异常"""
+
+    def __init__(self, path=None):
+        paths = [path] if path else candidate_paths()
+        errors = []
+        self.path = None
+        for candidate in paths:
+            if os.path.sep in candidate and not os.path.exists(candidate):
+                errors.append("%s: 文件不存在" % candidate)
+                continue
+            try:
+                self._lib = ctypes.CDLL(candidate)
+            except OSError as e:
+                # 常见原因：32/64位不一致、缺少依赖或文件不可读
+                errors.append("%s: %s" % (candidate, e))
+                continue
+            self.path = candidate
+            break
+        if self.path is None:
+            raise LibraryNotFound(
+                "找不到可用的%s（Python %s位, %s %s），已尝试:\n  %s\n可通过环境变量%s指定完整路径"
+                % (
+                    library_filename(),
+                    64 if sys.maxsize > 2**32 else 32,
+                    platform.system(),
+                    platform.machine(),
+                    "\n  ".join(errors),
+                    LIBRARY_ENV,
+                )
+            )
+        self._lib.FreeCString.argtypes = [ctypes.c_void_p]
+        self._lib.FreeCString.restype = None
+        for name, argtypes in _SIGNATURES.items():
+            func = getattr(self._lib, name, None)
+            if func is not None:
+                func.argtypes = argtypes
+                func.restype = ctypes.c_void_p
+        self.capabilities = self._load_capabilities()
+        self.version = self.capabilities["version"]
+        self.features = frozenset(self.capabilities.get("features", ()))
+
+    def _load_capabilities(self):
+        """读取库的版本与功能，版本不兼容时直接报错"""
+        if not self.has("Capabilities"):
+            raise IncompatibleLibrary(
+                "%s未导出Capabilities，可能是libproxy5/libproxy6等旧版构建，需要%s以上版本的lib_requests_go"
+                % (self.path, ".".join(map(str, MIN_LIB_VERSION)))
+            )
+        caps = self.call("Capabilities")
+        version = tuple(int(p) for p in caps["version"].split("-")[0].split("."))
+        if version[0] != MIN_LIB_VERSION[0] or version < MIN_LIB_VERSION:
+            raise IncompatibleLibrary(
+                "%s的版本为%s（commit %s），与本封装要求的%s.x（>=%s）不兼容"
+                % (self.path, caps["version"], caps.get("commit") or "未知", MIN_LIB_VERSION[0], ".".join(map(str, MIN_LIB_VERSION)))
+            )
+        return caps
+
+    def has(self, name):
+        """库是否导出了指定函数（旧版本构建可能缺少部分接口）"""
+        return getattr(self._lib, name, None) is not None
+
+    def require(self, *features):
+        """确认库支持指定功能（见Capabilities的features），不支持时抛出IncompatibleLibrary"""
+        missing = [f for f in features if f not in self.features]
+        if missing:
+            raise IncompatibleLibrary("%s（版本%s）不支持: %s" % (self.path, self.version, ", ".join(missing)))
+
+    def call_raw(self, name, *args):
+        """调用导出函数并返回完整的结果字典，负责释放返回的字符串"""
+        func = getattr(self._lib, name, None)
+        if func is None:
+            raise LibraryNotFound("%s未导出%s，请更新共享库" % (self.path, name))
+        ptr = func(*[_encode(a) for a in args])
+        if not ptr:
+            raise LibraryNotFound("%s返回了空指针" % name)
+        try:
+            data = ctypes.string_at(ptr)
+        finally:
+            self._lib.FreeCString(ptr)
+        return json.loads(data.decode("utf-8"))
+
+    def call(self, name, *args):
+        """调用导出函数，成功时返回result，失败时按error_code抛出异常"""
+        return unwrap(self.call_raw(name, *args))
+
+
+def unwrap(envelope):
+    """从{success, error, result, error_code}中取出result"""
+    if not envelope.get("success"):
+        raise error_from_code(envelope.get("error_code"), envelope.get("error") or "")
+    return envelope.get("result")
+
+
+def _encode(value):
+    if isinstance(value, str):
+        return value.encode("utf-8")
+    if isinstance(value, (dict, list)):
+        return json.dumps(value).encode("utf-8")
+    return value
+
+
+_default = None
+_default_lock = threading.Lock()
+
+
+def load_library(path=None):
+    """加载共享库；未指定path时返回进程内共享的默认实例"""
+    global _default
+    if path is not None:
+        return Library(path)
+    with _default_lock:
+        if _default is None:
+            _default = Library()
+        return _default
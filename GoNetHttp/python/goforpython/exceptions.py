@@ -0,0 +1,171 @@
+# -*- coding: utf-8 -*-
+"""
+与Go库error_code对应的异常类型
+
+层次结构与requests保持相近，便于从requests迁移：
+    RequestException
+    ├── LibraryNotFound       找不到或无法加载共享库
+    │   └── IncompatibleLibrary  版本过旧或缺少所需功能
+    ├── InvalidRequest        请求描述错误（4001~4008、4020、4021）
+    │   ├── InvalidMethod
+    │   └── MissingUserAgent
+    ├── ConfigError           客户端、代理、TLS、认证等配置错误
+    │   └── ProxyError
+    ├── UnknownHandle         句柄或请求ID已失效
+    ├── CassetteMiss          回放模式下无匹配记录
+    ├── DestinationBlocked    目标地址被安全策略拒绝
+    ├── CookieImportError
+    ├── TooManyRedirects
+    ├── ConnectionError       网络错误
+    │   ├── DNSError
+    │   └── Timeout
+    │       └── QueueTimeout  限流排队超时
+    ├── ReadError             读取响应失败
+    ├── TokenError            OAuth2令牌获取失败
+    ├── EventStreamError
+    ├── WebSocketError
+    ├── BodyWriteError        请求体写入失败或被中止
+    └── HTTPError             Response.raise_for_status()
+"""
+
+
+class RequestException(IOError):
+    """所有错误的基类，code为Go库返回的error_code（HTTPError与库加载错误为None）"""
+
+    def __init__(self, message="", code=None, response=None):
+        super().__init__(message)
+        self.message = message
+        self.code = code
+        self.response = response
+
+
+class LibraryNotFound(RequestException, OSError):
+    """找不到或无法加载共享库"""
+
+
+class IncompatibleLibrary(LibraryNotFound):
+    """共享库版本过旧或缺少所需功能"""
+
+
+class InvalidRequest(RequestException, ValueError):
+    pass
+
+
+class InvalidMethod(InvalidRequest):
+    pass
+
+
+class MissingUserAgent(InvalidRequest):
+    pass
+
+
+class ConfigError(RequestException, ValueError):
+    pass
+
+
+class ProxyError(ConfigError):
+    pass
+
+
+class UnknownHandle(RequestException, KeyError):
+    pass
+
+
+class CassetteMiss(RequestException):
+    pass
+
+
+class DestinationBlocked(RequestException):
+    pass
+
+
+class CookieImportError(RequestException, ValueError):
+    pass
+
+
+class TooManyRedirects(RequestException):
+    pass
+
+
+class ConnectionError(RequestException):
+    pass
+
+
+class DNSError(ConnectionError):
+    pass
+
+
+class Timeout(ConnectionError):
+    pass
+
+
+class QueueTimeout(Timeout):
+    pass
+
+
+class ReadError(RequestException):
+    pass
+
+
+class TokenError(RequestException):
+    pass
+
+
+class EventStreamError(RequestException):
+    pass
+
+
+class WebSocketError(RequestException):
+    pass
+
+
+class BodyWriteError(RequestException):
+    pass
+
+
+class HTTPError(RequestException):
+    pass
+
+
+# error_code与异常类型的对应关系，见gonethttp/errors.go中的错误代码定义
+ERROR_CODES = {
+    3001: TooManyRedirects,
+    4001: InvalidMethod,
+    4002: InvalidRequest,
+    4003: MissingUserAgent,
+    4004: ProxyError,
+    4005: InvalidRequest,
+    4006: CassetteMiss,
+    4007: ConfigError,
+    4008: InvalidRequest,
+    4009: UnknownHandle,
+    4010: UnknownHandle,
+    4011: ConfigError,
+    4012: ConfigError,
+    4013: ConfigError,
+    4014: ConfigError,
+    4015: ConfigError,
+    4016: DestinationBlocked,
+    4017: ConfigError,
+    4018: ConfigError,
+    4019: UnknownHandle,
+    4020: InvalidRequest,
+    4021: InvalidRequest,
+    4022: CookieImportError,
+    5001: ConnectionError,
+    5002: ReadError,
+    5003: QueueTimeout,
+    5004: DNSError,
+    5005: TokenError,
+    5006: EventStreamError,
+    5007: WebSocketError,
+    5008: BodyWriteError,
+}
+
+
+def error_from_code(code, message):
+    """按error_code构造异常，超时类网络错误归为Timeout"""
+    cls = ERROR_CODES.get(code, RequestException)
+    if cls is ConnectionError and ("超时" in message or "timeout" in message.lower()):
+        cls = Timeout
+    return cls(message, code=code)
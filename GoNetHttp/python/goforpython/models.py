@@ -0,0 +1,204 @@
+# -*- coding: utf-8 -*-
+"""
+Response：与requests.Response用法一致的响应对象
+"""
+import base64
+import json as _json
+import re
+from collections.abc import Mapping, MutableMapping
+
+from .exceptions import HTTPError
+
+# ReadBody单次读取的默认字节数
+DEFAULT_CHUNK_SIZE = 64 * 1024
+
+
+class CaseInsensitiveDict(MutableMapping):
+    """键不区分大小写的字典，保留最后一次设置时的大小写"""
+
+    def __init__(self, data=None, **kwargs):
+        self._store = {}
+        self.update(data or {}, **kwargs)
+
+    def __setitem__(self, key, value):
+        self._store[key.lower()] = (key, value)
+
+    def __getitem__(self, key):
+        return self._store[key.lower()][1]
+
+    def __delitem__(self, key):
+        del self._store[key.lower()]
+
+    def __iter__(self):
+        return (key for key, _ in self._store.values())
+
+    def __len__(self):
+        return len(self._store)
+
+    def __eq__(self, other):
+        if not isinstance(other, Mapping):
+            return NotImplemented
+        return dict(self.lower_items()) == dict(CaseInsensitiveDict(other).lower_items())
+
+    def lower_items(self):
+        return ((lower, kv[1]) for lower, kv in self._store.items())
+
+    def copy(self):
+        return CaseInsensitiveDict(self._store.values())
+
+    def __repr__(self):
+        return repr(dict(self.items()))
+
+
+class Response:
+    """
+    HTTP响应
+
+    与requests.Response相同的属性：status_code、reason、headers、url、history、
+    content、text、encoding、cookies、ok、json()、raise_for_status()、iter_content()；
+    额外属性：cookie_details（全部Cookie属性）、protocol、remote_addr、raw_result（库返回的原始字典）
+    """
+
+    def __init__(self):
+        self.status_code = None
+        self.reason = ""
+        self.headers = CaseInsensitiveDict()
+        self.url = ""
+        self.history = []
+        self.encoding = None
+        self.cookies = {}
+        self.cookie_details = []
+        self.protocol = ""
+        self.remote_addr = ""
+        self.raw_result = {}
+        self.request = None
+        self._content = None
+        self._stream = None  # 流式模式：(library, response_id)
+
+    @classmethod
+    def from_result(cls, result, request=None):
+        """由DoRequest/OpenResponse的result构造"""
+        r = cls()
+        r.raw_result = result
+        r.request = request
+        r.status_code = result.get("status_code")
+        status = result.get("status", "")
+        r.reason = status.split(" ", 1)[1] if " " in status else status
+        r.headers = CaseInsensitiveDict(
+            {k: ", ".join(v) if k.lower() != "set-cookie" else "\n".join(v) for k, v in (result.get("headers") or {}).items()}
+        )
+        r.protocol = result.get("protocol", "")
+        r.remote_addr = result.get("remote_addr", "")
+        redirects = result.get("redirects") or []
+        r.url = redirects[0] if redirects else (request.url if request else "")
+        r.cookie_details = result.get("cookies") or []
+        r.cookies = {c["name"]: c["value"] for c in r.cookie_details}
+        r.encoding = _charset(result.get("content_type", ""))
+        hops = result.get("hops") or []
+        r.history = [cls._from_hop(hop) for hop in hops[:-1]]
+        if "byte" in result:
+            r._content = base64.b64decode(result["byte"] or "")
+        return r
+
+    @classmethod
+    def _from_hop(cls, hop):
+        r = cls()
+        r.status_code = hop.get("status_code")
+        r.url = hop.get("url", "")
+        r.cookie_details = hop.get("cookies") or []
+        r.cookies = {c["name"]: c["value"] for c in r.cookie_details}
+        set_cookie = hop.get("set_cookie") or []
+        if set_cookie:
+            r.headers["Set-Cookie"] = "\n".join(set_cookie)
+        r._content = b""
+        return r
+
+    @property
+    def ok(self):
+        return self.status_code is not None and self.status_code < 400
+
+    @property
+    def is_redirect(self):
+        return "location" in self.headers and self.status_code in (301, 302, 303, 307, 308)
+
+    @property
+    def content(self):
+        """响应体字节；流式模式下首次访问时读取剩余全部内容"""
+        if self._content is None:
+            self._content = b"".join(self.iter_content(DEFAULT_CHUNK_SIZE))
+        return self._content
+
+    @property
+    def text(self):
+        encoding = self.encoding or "utf-8"
+        try:
+            return self.content.decode(encoding, errors="replace")
+        except LookupError:
+            return self.content.decode("utf-8", errors="replace")
+
+    def json(self, **kwargs):
+        return _json.loads(self.content, **kwargs)
+
+    def iter_content(self, chunk_size=DEFAULT_CHUNK_SIZE):
+        """分块迭代响应体；非流式响应直接切分已读取的内容"""
+        if self._stream is None:
+            data = self._content or b""
+            size = chunk_size or len(data) or 1
+            for i in range(0, len(data), size):
+                yield data[i : i + size]
+            return
+        lib, response_id = self._stream
+        try:
+            while True:
+                chunk = lib.call("ReadBody", response_id, chunk_size or DEFAULT_CHUNK_SIZE)
+                data = base64.b64decode(chunk.get("byte") or "")
+                if data:
+                    yield data
+                if chunk.get("eof"):
+                    break
+        finally:
+            self.close()
+
+    def iter_lines(self, chunk_size=DEFAULT_CHUNK_SIZE, delimiter=None):
+        """逐行迭代响应体（bytes，不含行尾）"""
+        pending = b""
+        for chunk in self.iter_content(chunk_size):
+            pending += chunk
+            lines = pending.split(delimiter) if delimiter else pending.splitlines()
+            # 最后一行未结束时留到下一块
+            if lines and lines[-1] and pending.endswith(lines[-1]):
+                pending = lines.pop()
+            else:
+                pending = b""
+            yield from lines
+        if pending:
+            yield pending
+
+    def close(self):
+        """释放流式响应的连接，非流式响应无需调用"""
+        if self._stream is not None:
+            lib, response_id = self._stream
+            self._stream = None
+            lib.call_raw("CloseResponse", response_id)
+
+    def raise_for_status(self):
+        if 400 <= (self.status_code or 0) < 600:
+            kind = "Client Error" if self.status_code < 500 else "Server Error"
+            raise HTTPError("%s %s: %s for url: %s" % (self.status_code, kind, self.reason, self.url), response=self)
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *args):
+        self.close()
+
+    def __bool__(self):
+        return self.ok
+
+    def __repr__(self):
+        return "<Response [%s]>" % self.status_code
+
+
+def _charset(content_type):
+    match = re.search(r"charset=([\w.-]+)", content_type or "", re.I)
+    return match.group(1).strip("\"'") if match else None
@@ -0,0 +1,289 @@
+# -*- coding: utf-8 -*-
+"""
+Session：与requests.Session用法一致的会话
+
+每个Session对应Go库中的一个客户端句柄（NewClient），句柄内共享连接池、Cookie罐、
+限流、DNS、缓存等配置；请求参数转换为请求描述后调用DoRequest/OpenResponse。
+"""
+import json as _json
+import os
+import threading
+from urllib.parse import urlencode, urlsplit, urlunsplit
+
+from . import __version__
+from ._lib import REQUEST_CALLBACK, load_library, unwrap
+from .exceptions import InvalidRequest
+from .models import CaseInsensitiveDict, Response
+
+# 请求描述中直接透传的Go扩展参数，含义见README
+SPEC_OPTIONS = (
+    "protocol",
+    "tls_profile",
+    "header_profile",
+    "user_agent_policy",
+    "local_address",
+    "sign",
+    "compress",
+    "body_source",
+)
+
+# WriteBody单次写入的字节数
+WRITE_CHUNK_SIZE = 256 * 1024
+
+
+class PreparedRequest:
+    """发送前的请求信息，供Response.request引用"""
+
+    def __init__(self, method, url, headers, spec):
+        self.method = method
+        self.url = url
+        self.headers = headers
+        self.spec = spec
+
+
+class Session:
+    """
+    会话
+
+    参数:
+        client_config: NewClient的配置字典（limits、dns、bind、oauth、cache等），cookie_jar默认开启
+        library:       共享库路径，默认按_lib.candidate_paths()查找
+    """
+
+    def __init__(self, client_config=None, library=None):
+        self._lib = load_library(library)
+        self._lib.require("client")
+        config = {"cookie_jar": True}
+        config.update(client_config or {})
+        if config.get("cookie_jar"):
+            self._lib.require("cookie_jar")
+        self._client_id = self._lib.call("NewClient", config)["client_id"]
+        self.headers = CaseInsensitiveDict({"User-Agent": "goforpython/%s" % __version__, "Accept": "*/*"})
+        self.cookies = {}
+        self.proxies = {}
+        self.auth = None
+        self.params = {}
+        self.timeout = None
+        self.options = {}
+
+    @property
+    def client_id(self):
+        return self._client_id
+
+    def request(
+        self,
+        method,
+        url,
+        params=None,
+        data=None,
+        headers=None,
+        cookies=None,
+        files=None,
+        auth=None,
+        timeout=None,
+        allow_redirects=True,
+        proxies=None,
+        json=None,
+        stream=False,
+        **options
+    ):
+        """
+        发送请求，参数与requests.Session.request一致
+
+        data:    dict/列表（表单编码）、str、bytes，或已打开的文件对象（以文件路径流式发送）
+        timeout: 秒，元组(connect, read)时取两者之和；stream=True时只约束收到响应头之前
+        options: Go扩展参数，见SPEC_OPTIONS
+        """
+        if files:
+            raise InvalidRequest("不支持files参数，请使用data传入文件对象或body_source")
+        unknown = set(options) - set(SPEC_OPTIONS)
+        if unknown:
+            raise TypeError("未知的参数: %s" % ", ".join(sorted(unknown)))
+        method = method.upper()
+        url = _add_params(url, dict(self.params, **(params or {})))
+        merged = self.headers.copy()
+        for key, value in (headers or {}).items():
+            if value is None:
+                merged.pop(key, None)
+            else:
+                merged[key] = value
+        spec = {
+            "method": method,
+            "url": url,
+            "headers": {},
+            "proxy": _select_proxy(url, dict(self.proxies, **(proxies or {}))),
+            "disable_redirect": not allow_redirects,
+            "body": "",
+            "client": self._client_id,
+        }
+        spec.update({k: v for k, v in self.options.items() if v is not None})
+        spec.update({k: v for k, v in options.items() if v is not None})
+        writer_data = self._encode_body(spec, merged, data, json)
+        spec["headers"] = dict(merged.items())
+        send_cookies = dict(self.cookies, **(cookies or {}))
+        if send_cookies:
+            spec["cookies"] = send_cookies
+        auth = auth if auth is not None else self.auth
+        if auth is not None:
+            spec["auth"] = _auth_config(auth)
+        timeout = timeout if timeout is not None else self.timeout
+        if timeout is not None:
+            if isinstance(timeout, tuple):
+                timeout = sum(t for t in timeout if t is not None)
+            spec["timeout_ms"] = int(timeout * 1000)
+        prepared = PreparedRequest(method, url, merged, spec)
+        for feature in ("body_source", "compress", "sign", "tls_profile", "header_profile", "protocol", "local_address"):
+            if spec.get(feature):
+                self._lib.require(feature)
+        if stream:
+            self._lib.require("streaming_response")
+            if writer_data is not None:
+                raise InvalidRequest("stream=True时data不能是非UTF-8的bytes")
+            result = self._lib.call("OpenResponse", spec)
+            response = Response.from_result(result, prepared)
+            response._stream = (self._lib, result["response_id"])
+            return response
+        if writer_data is not None:
+            result = self._send_with_writer(spec, writer_data)
+        else:
+            result = self._lib.call("DoRequest", spec)
+        return Response.from_result(result, prepared)
+
+    def _encode_body(self, spec, headers, data, json):
+        """
+        按requests的规则编码请求体，写入spec
+        返回值: 非UTF-8的bytes需通过请求体写入器发送，此时返回该数据，否则返回None
+        """
+        if json is not None:
+            spec["body"] = _json.dumps(json, ensure_ascii=False)
+            headers.setdefault("Content-Type", "application/json")
+            return None
+        if data is None or data == b"" or data == "":
+            return None
+        if isinstance(data, (dict, list, tuple)):
+            # Go库对该Content-Type的body按查询串重新编码
+            spec["body"] = urlencode(data, doseq=True)
+            headers.setdefault("Content-Type", "application/x-www-form-urlencoded")
+            return None
+        if hasattr(data, "read"):
+            name = getattr(data, "name", None)
+            if not isinstance(name, str) or not os.path.isfile(name):
+                raise InvalidRequest("文件对象必须对应磁盘上的文件")
+            spec["body_source"] = {"type": "file", "path": os.path.abspath(name), "offset": data.tell()}
+            return None
+        if isinstance(data, str):
+            spec["body"] = data
+            return None
+        if isinstance(data, (bytes, bytearray, memoryview)):
+            data = bytes(data)
+            try:
+                spec["body"] = data.decode("utf-8")
+                return None
+            except UnicodeDecodeError:
+                return data
+        raise InvalidRequest("不支持的data类型: %s" % type(data).__name__)
+
+    def _send_with_writer(self, spec, data):
+        """通过请求体写入器发送二进制请求体：异步提交请求后分块写入，再等待结果"""
+        self._lib.require("body_source", "async")
+        writer_id = self._lib.call("OpenBodyWriter", len(data))["writer_id"]
+        spec = dict(spec, body="", body_source={"type": "writer", "writer_id": writer_id})
+        request_id = self._lib.call("SubmitRequest", spec, REQUEST_CALLBACK())["id"]
+        try:
+            for i in range(0, len(data), WRITE_CHUNK_SIZE):
+                chunk = data[i : i + WRITE_CHUNK_SIZE]
+                self._lib.call("WriteBody", writer_id, chunk, len(chunk))
+        except Exception:
+            # 请求已失败时写入会报错，以请求本身的错误为准
+            pass
+        finally:
+            self._lib.call_raw("CloseBodyWriter", writer_id, 0)
+        while True:
+            status = self._lib.call("WaitRequest", request_id, 1000)
+            if status["status"] == "done":
+                return unwrap(status["response"])
+
+    def get(self, url, **kwargs):
+        return self.request("GET", url, **kwargs)
+
+    def post(self, url, data=None, json=None, **kwargs):
+        return self.request("POST", url, data=data, json=json, **kwargs)
+
+    def import_cookies(self, source, format=""):
+        """
+        导入浏览器导出的Cookie到会话的Cookie罐
+        source: 文件路径或文件内容；format: netscape/json，默认自动识别
+        返回值: 导入的Cookie数
+        """
+        self._lib.require("cookie_import")
+        if isinstance(source, (str, os.PathLike)) and os.path.isfile(source):
+            with open(source, encoding="utf-8") as f:
+                source = f.read()
+        return self._lib.call("ImportCookies", self._client_id, source, format)["imported"]
+
+    def close(self):
+        """释放客户端句柄"""
+        if self._client_id:
+            client_id, self._client_id = self._client_id, 0
+            self._lib.call_raw("CloseClient", client_id)
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *args):
+        self.close()
+
+    def __del__(self):
+        try:
+            self.close()
+        except Exception:
+            pass
+
+
+def _add_params(url, params):
+    if not params:
+        return url
+    parts = urlsplit(url)
+    query = urlencode(params, doseq=True)
+    return urlunsplit(parts._replace(query=parts.query + "&" + query if parts.query else query))
+
+
+def _select_proxy(url, proxies):
+    """按requests的规则选择代理：scheme://host > scheme > all"""
+    if not proxies:
+        return ""
+    parts = urlsplit(url)
+    for key in ("%s://%s" % (parts.scheme, parts.hostname), parts.scheme, "all"):
+        if proxies.get(key):
+            return proxies[key]
+    return ""
+
+
+def _auth_config(auth):
+    """(用户名, 密码)元组为Basic认证，也可直接传入请求描述的auth字典"""
+    if isinstance(auth, dict):
+        return auth
+    if isinstance(auth, tuple) and len(auth) == 2:
+        return {"type": "basic", "username": auth[0], "password": auth[1]}
+    raise InvalidRequest("auth必须是(用户名, 密码)元组或auth配置字典")
+
+
+_default_session = None
+_default_lock = threading.Lock()
+
+
+def request(method, url, **kwargs):
+    """使用进程内共享的默认会话发送请求"""
+    global _default_session
+    with _default_lock:
+        if _default_session is None:
+            _default_session = Session()
+    return _default_session.request(method, url, **kwargs)
+
+
+def get(url, **kwargs):
+    return request("GET", url, **kwargs)
+
+
+def post(url, data=None, json=None, **kwargs):
+    return request("POST", url, data=data, json=json, **kwargs)
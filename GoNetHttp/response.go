@@ -0,0 +1,126 @@
+// response.go
+package main
+
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"main.go/gonethttp"
+)
+
+// defaultChunkSize ReadBody未指定maxBytes时单次最多返回的字节数
+const defaultChunkSize = 64 * 1024
+
+// streamResponse 流式读取中的响应
+type streamResponse struct {
+	id        int64
+	mu        sync.Mutex // 串行化ReadBody
+	body      io.ReadCloser
+	bytesRead int64
+	eof       bool
+}
+
+// openedResponse OpenResponse的返回值：响应句柄与响应信息
+type openedResponse struct {
+	ResponseID int64 `json:"response_id"`
+	gonethttp.ResponseInfo
+}
+
+var (
+	responseSeq int64 // 响应句柄ID自增序列
+	responsesMu sync.Mutex
+	responses   = map[int64]*streamResponse{}
+)
+
+// OpenResponse 以流式模式发起请求的C导出函数
+// 收到响应头后立即返回，响应体通过ReadBody分块读取，不受5MB上限限制
+// 参数 cSpec: JSON格式请求描述（字段同gonethttp.Request），timeout_ms只约束收到响应头之前的阶段
+// 返回值: {success, result:{response_id, status, status_code, headers, cookies, ...}}，需使用FreeCString释放
+//
+// 注意：读取完毕或放弃读取后都必须调用CloseResponse释放连接
+//
+//export OpenResponse
+func OpenResponse(cSpec *C.char) *C.char {
+	spec, err := parseSpec(cSpec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	res, err := gonethttp.Open(context.Background(), spec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	sr := &streamResponse{id: atomic.AddInt64(&responseSeq, 1), body: res.Body}
+	responsesMu.Lock()
+	responses[sr.id] = sr
+	responsesMu.Unlock()
+	return resultToC(openedResponse{ResponseID: sr.id, ResponseInfo: res.ResponseInfo}, nil)
+}
+
+// ReadBody 读取响应体的下一块
+// 参数 maxBytes: 本次最多返回的字节数，<=0时为64KB
+// 返回值: {byte, size, bytes_read, eof}，byte为本次数据（JSON中为base64），
+// bytes_read为累计读取字节数，eof为true表示响应体已读完
+// 有数据到达即返回，不等待凑满maxBytes
+//
+//export ReadBody
+func ReadBody(cHandle C.longlong, maxBytes C.int) *C.char {
+	sr, err := lookupResponse(int64(cHandle))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	size := int(maxBytes)
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	buf := make([]byte, size)
+	n := 0
+	for !sr.eof && n == 0 {
+		var errRead error
+		n, errRead = sr.body.Read(buf)
+		if errors.Is(errRead, io.EOF) {
+			sr.eof = true
+		} else if errRead != nil {
+			return resultToC(nil, fmt.Errorf("读取响应体失败: %v", errRead))
+		}
+	}
+	sr.bytesRead += int64(n)
+	return resultToC(map[string]interface{}{
+		"byte":       buf[:n],
+		"size":       n,
+		"bytes_read": sr.bytesRead,
+		"eof":        sr.eof,
+	}, nil)
+}
+
+// CloseResponse 关闭响应体并释放句柄，未读完时中止连接
+//
+//export CloseResponse
+func CloseResponse(cHandle C.longlong) *C.char {
+	responsesMu.Lock()
+	sr, ok := responses[int64(cHandle)]
+	delete(responses, int64(cHandle))
+	responsesMu.Unlock()
+	if !ok {
+		return resultToC(nil, fmt.Errorf("未知的句柄: 响应 %d", int64(cHandle)))
+	}
+	// 不等待ReadBody持有的锁，关闭后阻塞中的读取会立即返回
+	sr.body.Close()
+	return resultToC(map[string]interface{}{"response_id": sr.id}, nil)
+}
+
+func lookupResponse(id int64) (*streamResponse, error) {
+	responsesMu.Lock()
+	defer responsesMu.Unlock()
+	sr, ok := responses[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的句柄: 响应 %d", id)
+	}
+	return sr, nil
+}
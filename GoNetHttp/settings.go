@@ -0,0 +1,59 @@
+// settings.go
+package main
+
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"main.go/gonethttp"
+)
+
+// SetCassette 配置全局录制/回放模式
+// 参数 cConfig: JSON格式配置，见gonethttp.CassetteConfig；mode为off或空时关闭
+// 返回值: {success, result:{mode, entries}}，需使用FreeCString释放
+//
+//export SetCassette
+func SetCassette(cConfig *C.char) *C.char {
+	var cfg gonethttp.CassetteConfig
+	if err := json.Unmarshal([]byte(C.GoString(cConfig)), &cfg); err != nil {
+		return resultToC(nil, fmt.Errorf("cassette配置解析失败: %v", err))
+	}
+	entries, err := gonethttp.SetCassette(cfg)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{
+		"mode":    cfg.Mode,
+		"entries": entries,
+	}, nil)
+}
+
+// SetDestinationPolicy 设置全局目标地址安全策略的C导出函数
+// 参数 cConfig: JSON格式配置，见gonethttp.DestinationConfig；null或空字符串表示关闭
+// 返回值: {success, result}，需使用FreeCString释放
+//
+//export SetDestinationPolicy
+func SetDestinationPolicy(cConfig *C.char) *C.char {
+	var cfg *gonethttp.DestinationConfig
+	if raw := strings.TrimSpace(C.GoString(cConfig)); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return resultToC(nil, fmt.Errorf("目标策略配置错误: %v", err))
+		}
+	}
+	if err := gonethttp.SetDestinationPolicy(cfg); err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"enabled": cfg != nil}, nil)
+}
+
+// TLSFingerprint 本地生成指定配置的ClientHello并计算JA3/JA4指纹
+// 不发起网络连接，可用于校验指纹配置是否符合预期
+// 参数 cProfile: 配置名称，如chrome_133、firefox_120、safari_16、edge_85
+// 返回值: {success, result:{profile, ja3, ja3_hash, ja4, expected_ja4, match, http2}}，需使用FreeCString释放
+//
+//export TLSFingerprint
+func TLSFingerprint(cProfile *C.char) *C.char {
+	return resultToC(gonethttp.TLSFingerprint(C.GoString(cProfile)))
+}
@@ -0,0 +1,98 @@
+// sse.go
+package main
+
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"main.go/gonethttp"
+)
+
+var (
+	streamSeq int64 // 事件流ID自增序列
+	streamsMu sync.Mutex
+	streams   = map[int64]*gonethttp.EventStream{}
+)
+
+// OpenEventStream 打开Server-Sent Events事件流的C导出函数
+// 参数 cSpec: JSON格式请求描述（字段同gonethttp.Request），timeout_ms限制每次建立连接（收到响应头）的时间
+// 返回值: {success, result:{stream_id, response}}，response为首次连接的响应信息，需使用FreeCString释放
+//
+// 首次连接失败（网络错误、非200状态码或Content-Type不是text/event-stream）时直接返回错误；
+// 之后连接断开会按Last-Event-ID与服务器指定的retry间隔自动重连；单行或单个事件超过16MB时事件流以错误结束（error_code 5006）
+//
+//export OpenEventStream
+func OpenEventStream(cSpec *C.char) *C.char {
+	spec, err := parseSpec(cSpec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	es, err := gonethttp.OpenEventStream(context.Background(), spec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	id := atomic.AddInt64(&streamSeq, 1)
+	streamsMu.Lock()
+	streams[id] = es
+	streamsMu.Unlock()
+	return resultToC(map[string]interface{}{"stream_id": id, "response": es.Response()}, nil)
+}
+
+// NextEvent 取出事件流的下一条事件
+// 参数 timeoutMs: 最长等待毫秒数，0表示不等待
+// 返回值:
+//
+//	{status:"event", event:{id, event, data, retry}}
+//	{status:"timeout"}：等待期间没有新事件
+//	{status:"closed"}：事件流已结束且事件已取完；因错误结束时返回错误
+//
+//export NextEvent
+func NextEvent(cHandle C.longlong, timeoutMs C.int) *C.char {
+	es, err := lookupStream(int64(cHandle))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+	ev, err := es.Next(ctx)
+	switch {
+	case err == nil:
+		return resultToC(map[string]interface{}{"status": "event", "event": ev}, nil)
+	case errors.Is(err, io.EOF):
+		return resultToC(map[string]interface{}{"status": "closed"}, nil)
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		return resultToC(map[string]interface{}{"status": "timeout"}, nil)
+	}
+	return resultToC(nil, err)
+}
+
+// CloseEventStream 关闭事件流并释放句柄
+//
+//export CloseEventStream
+func CloseEventStream(cHandle C.longlong) *C.char {
+	streamsMu.Lock()
+	es, ok := streams[int64(cHandle)]
+	delete(streams, int64(cHandle))
+	streamsMu.Unlock()
+	if !ok {
+		return resultToC(nil, fmt.Errorf("未知的句柄: 事件流 %d", int64(cHandle)))
+	}
+	es.Close()
+	return resultToC(map[string]interface{}{"stream_id": int64(cHandle)}, nil)
+}
+
+func lookupStream(id int64) (*gonethttp.EventStream, error) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	es, ok := streams[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的句柄: 事件流 %d", id)
+	}
+	return es, nil
+}
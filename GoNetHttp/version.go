@@ -0,0 +1,105 @@
+// version.go
+package main
+
+import "C"
+import (
+	"runtime"
+	"runtime/debug"
+
+	"main.go/gonethttp"
+)
+
+// libVersion 共享库的语义化版本
+// 新增导出函数或请求描述字段时增加次版本号，修改已有函数签名或返回结构时增加主版本号
+const libVersion = "1.2.0"
+
+// 构建信息，可在构建时通过-ldflags注入：
+//
+//	go build -buildmode=c-shared -ldflags "-X main.buildCommit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" .
+//
+// 未注入buildCommit时使用Go工具链记录的版本控制信息
+var (
+	buildCommit string
+	buildTime   string
+)
+
+// features 库支持的功能，供调用方判断是否可用
+var features = []string{
+	"async",              // SubmitRequest/PollRequest/WaitRequest
+	"auth",               // 请求描述的auth字段：basic/bearer/digest
+	"batch",              // BatchRequest
+	"body_source",        // 请求描述的body_source字段与OpenBodyWriter/WriteBody/CloseBodyWriter
+	"cassette",           // SetCassette录制/回放
+	"client",             // NewClient/CloseClient
+	"compress",           // 请求描述的compress字段
+	"cookie_import",      // ImportCookies
+	"cookie_jar",         // 客户端配置的cookie_jar
+	"destination_policy", // SetDestinationPolicy与客户端配置的destination_policy
+	"dns",                // 客户端配置的dns
+	"event_stream",       // OpenEventStream/NextEvent/CloseEventStream
+	"header_profile",     // 请求描述的header_profile字段
+	"http_cache",         // 客户端配置的cache
+	"legacy_signatures",  // PostUrlWithProxyV1/PostUrlWithProxyV2
+	"local_address",      // 请求描述的local_address字段与客户端配置的bind
+	"oauth2",             // 客户端配置的oauth
+	"progress",           // SubmitRequestWithProgress/RequestProgress
+	"protocol",           // 请求描述的protocol字段
+	"rate_limit",         // 客户端配置的limits
+	"sign",               // 请求描述与客户端配置的sign
+	"streaming_response", // OpenResponse/ReadBody/CloseResponse
+	"tls_profile",        // 请求描述的tls_profile字段与TLSFingerprint
+	"websocket",          // OpenWebSocket/WebSocketSend/WebSocketReceive/CloseWebSocket
+	"websocket_limit",    // 请求描述的max_message_bytes字段
+}
+
+// LibVersion 返回共享库版本与构建信息
+// 返回值: {success, result:{version, go_version, commit, commit_time, build_time, modified, os, arch}}
+// 未注入且无版本控制信息时commit等字段为空字符串，modified表示构建时工作区有未提交的修改
+//
+//export LibVersion
+func LibVersion() *C.char {
+	return resultToC(versionInfo(), nil)
+}
+
+// Capabilities 返回版本信息与支持的功能
+// 返回值: {success, result:{version, ..., features, tls_profiles, header_profiles, protocols, compress, auth_types, sign_types}}
+// 调用方可据此判断功能是否可用，在加载到不兼容的库时给出明确提示
+//
+//export Capabilities
+func Capabilities() *C.char {
+	result := versionInfo()
+	result["features"] = features
+	for key, values := range gonethttp.Supported() {
+		result[key] = values
+	}
+	return resultToC(result, nil)
+}
+
+// versionInfo 版本与构建信息
+func versionInfo() map[string]interface{} {
+	commit, commitTime, modified := buildCommit, "", false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = s.Value
+				}
+			case "vcs.time":
+				commitTime = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+	}
+	return map[string]interface{}{
+		"version":     libVersion,
+		"go_version":  runtime.Version(),
+		"commit":      commit,
+		"commit_time": commitTime,
+		"build_time":  buildTime,
+		"modified":    modified,
+		"os":          runtime.GOOS,
+		"arch":        runtime.GOARCH,
+	}
+}
@@ -0,0 +1,139 @@
+// websocket.go
+package main
+
+import "C"
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"main.go/gonethttp"
+)
+
+// wsMessage WebSocketSend的参数
+type wsMessage struct {
+	Type string `json:"type"` // text（默认）/binary/ping/pong
+	Data string `json:"data"` // 文本内容；binary时为base64编码的数据
+}
+
+var (
+	socketSeq int64 // WebSocket ID自增序列
+	socketsMu sync.Mutex
+	sockets   = map[int64]*gonethttp.WebSocket{}
+)
+
+// OpenWebSocket 建立WebSocket连接的C导出函数
+// 参数 cSpec: JSON格式请求描述（字段同gonethttp.Request），url为ws://或wss://，
+// 支持headers、cookies、proxy（http/https/socks5）、client、tls_profile、local_address，
+// timeout_ms限制握手时间（默认45秒）；请求头Sec-WebSocket-Protocol用于协商子协议；
+// max_message_bytes限制单条消息大小（默认16MB）
+// 返回值: {success, result:{socket_id, status_code, headers, subprotocol}}，需使用FreeCString释放
+//
+//export OpenWebSocket
+func OpenWebSocket(cSpec *C.char) *C.char {
+	spec, err := parseSpec(cSpec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	ws, res, err := gonethttp.DialWebSocket(spec)
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	id := atomic.AddInt64(&socketSeq, 1)
+	socketsMu.Lock()
+	sockets[id] = ws
+	socketsMu.Unlock()
+	return resultToC(map[string]interface{}{
+		"socket_id":   id,
+		"status_code": res.StatusCode,
+		"headers":     res.Header,
+		"subprotocol": ws.Subprotocol(),
+	}, nil)
+}
+
+// WebSocketSend 发送一帧
+// 参数 cMessage: {"type": "text", "data": "..."}，binary时data为base64，ping/pong的data为负载
+//
+//export WebSocketSend
+func WebSocketSend(cHandle C.longlong, cMessage *C.char) *C.char {
+	ws, err := lookupSocket(int64(cHandle))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	var msg wsMessage
+	if err := json.Unmarshal([]byte(C.GoString(cMessage)), &msg); err != nil {
+		return resultToC(nil, fmt.Errorf("请求参数解析失败: %v", err))
+	}
+	data := []byte(msg.Data)
+	if msg.Type == "binary" {
+		if data, err = base64.StdEncoding.DecodeString(msg.Data); err != nil {
+			return resultToC(nil, fmt.Errorf("请求参数解析失败: binary数据不是有效的base64"))
+		}
+	}
+	if err := ws.Send(msg.Type, data); err != nil {
+		return resultToC(nil, err)
+	}
+	return resultToC(map[string]interface{}{"socket_id": int64(cHandle)}, nil)
+}
+
+// WebSocketReceive 接收下一帧
+// 参数 timeoutMs: 最长等待毫秒数，0表示不等待
+// 返回值:
+//
+//	{status:"frame", frame:{type, data/byte}}：收到的消息，或ping/pong控制帧（收到ping时已自动回复pong）
+//	{status:"timeout"}
+//	{status:"closed", frame:{type:"close", code, reason}}：连接已关闭且帧已取完
+//	消息超过max_message_bytes时连接以1009关闭，返回status为closed的结果并附带错误（error_code 5007）
+//
+//export WebSocketReceive
+func WebSocketReceive(cHandle C.longlong, timeoutMs C.int) *C.char {
+	ws, err := lookupSocket(int64(cHandle))
+	if err != nil {
+		return resultToC(nil, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+	frame, err := ws.Receive(ctx)
+	switch {
+	case err == nil:
+		return resultToC(map[string]interface{}{"status": "frame", "frame": frame}, nil)
+	case errors.Is(err, io.EOF):
+		return resultToC(map[string]interface{}{"status": "closed", "frame": frame}, nil)
+	case errors.Is(err, context.DeadlineExceeded):
+		return resultToC(map[string]interface{}{"status": "timeout"}, nil)
+	}
+	return resultToC(map[string]interface{}{"status": "closed", "frame": frame}, err)
+}
+
+// CloseWebSocket 发送关闭帧并释放句柄
+// 参数 code: 关闭码，0表示1000（正常关闭）
+// 等待服务器回应关闭帧最多2秒后断开连接
+//
+//export CloseWebSocket
+func CloseWebSocket(cHandle C.longlong, code C.int, cReason *C.char) *C.char {
+	socketsMu.Lock()
+	ws, ok := sockets[int64(cHandle)]
+	delete(sockets, int64(cHandle))
+	socketsMu.Unlock()
+	if !ok {
+		return resultToC(nil, fmt.Errorf("未知的句柄: WebSocket %d", int64(cHandle)))
+	}
+	frame := ws.Close(int(code), C.GoString(cReason))
+	return resultToC(map[string]interface{}{"socket_id": int64(cHandle), "frame": frame}, nil)
+}
+
+func lookupSocket(id int64) (*gonethttp.WebSocket, error) {
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+	ws, ok := sockets[id]
+	if !ok {
+		return nil, fmt.Errorf("未知的句柄: WebSocket %d", id)
+	}
+	return ws, nil
+}